@@ -0,0 +1,374 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/ranking"
+)
+
+// completeNamesCommand is the hidden subcommand the completion scripts call to
+// list prompt names.
+const completeNamesCommand = "__complete-names"
+
+type completionFlag struct {
+	name        string
+	description string
+	values      []string // fixed choices for the flag's value
+	dir         bool     // the value is a directory
+	takesValue  bool
+	repeatable  bool // may be given more than once
+}
+
+type completionCommand struct {
+	name        string
+	description string
+	flags       []completionFlag
+	promptArgs  bool     // positional arguments are prompt names
+	args        []string // fixed choices for positional arguments
+}
+
+var (
+	completionDirFlag    = completionFlag{name: "dir", description: "Prompt directories (comma separated)", takesValue: true, dir: true}
+	completionVarFlag    = completionFlag{name: "var", description: "Template variable as key=value", takesValue: true, repeatable: true}
+	completionFormatFlag = completionFlag{name: "format", description: "Output format", takesValue: true, values: []string{formatText, formatJSON, formatNDJSON, formatTSV}}
+)
+
+// completionGlobalFlags are stripped by run before any subcommand, so they
+// are offered for every command.
+var completionGlobalFlags = []completionFlag{
+	{name: "config", description: "Settings file to use", takesValue: true},
+	{name: "strict", description: "Fail on the first file that cannot be loaded"},
+	{name: "rank", description: "Ranking preset", takesValue: true, values: ranking.Names},
+}
+
+// allFlags returns the command's own flags followed by the global flags.
+func (c completionCommand) allFlags() []completionFlag {
+	return append(append([]completionFlag(nil), c.flags...), completionGlobalFlags...)
+}
+
+// completionCommands mirrors the subcommands dispatched by run and their flags.
+var completionCommands = []completionCommand{
+	{name: "pick", description: "Pick a prompt", flags: []completionFlag{
+		completionDirFlag,
+		{name: "query", description: "Query to select a prompt non-interactively", takesValue: true},
+		{name: "interactive", description: "Force interactive selection"},
+		{name: "copy", description: "Copy the chosen prompt to the clipboard"},
+		completionVarFlag,
+	}},
+	{name: "search", description: "Search prompts", flags: []completionFlag{
+		completionDirFlag,
+		{name: "limit", description: "Maximum number of results", takesValue: true},
+		{name: "interactive", description: "Launch interactive picker with the query"},
+		{name: "explain", description: "Show how each result was scored"},
+		completionFormatFlag,
+	}},
+	{name: "ls", description: "List prompts", flags: []completionFlag{
+		completionDirFlag,
+		completionFormatFlag,
+		{name: "diagnostics", description: "List problems found while loading"},
+	}},
+	{name: "cat", description: "Print a prompt", promptArgs: true, flags: []completionFlag{completionDirFlag, completionVarFlag, completionFormatFlag}},
+	{name: "mesh", description: "Concatenate prompts", promptArgs: true, flags: []completionFlag{
+		completionDirFlag,
+		completionVarFlag,
+		{name: "interactive", description: "Pick the prompts in the picker"},
+	}},
+	{name: "new", description: "Create a prompt file", flags: []completionFlag{
+		{name: "dir", description: "Directory to create the prompt in", takesValue: true, dir: true},
+		{name: "tags", description: "Tags (comma separated)", takesValue: true},
+		{name: "aliases", description: "Aliases (comma separated)", takesValue: true},
+		{name: "summary", description: "One line summary", takesValue: true},
+	}},
+	{name: "edit", description: "Open a prompt in the editor", promptArgs: true, flags: []completionFlag{completionDirFlag}},
+	{name: "completion", description: "Print a shell completion script", args: []string{"bash", "zsh", "fish"}},
+	{name: "config", description: "Show, edit and validate the settings", args: []string{"show", "get", "set", "path", "validate"}},
+}
+
+func runCompletion(args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("completion requires a shell: bash, zsh or fish")
+	}
+
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion()
+	case "zsh":
+		script = zshCompletion()
+	case "fish":
+		script = fishCompletion()
+	default:
+		return fmt.Errorf("unsupported shell %q (use bash, zsh or fish)", args[0])
+	}
+
+	_, err := io.WriteString(out, script)
+	return err
+}
+
+// runCompleteNames prints one prompt reference per line for the completion
+// scripts.
+func runCompleteNames(ctx appContext, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(completeNamesCommand, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var dirFlag string
+	fs.StringVar(&dirFlag, "dir", "", "Prompt directories (comma separated)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	prompts, err := loadPrompts(ctx, dirFlag)
+	if err != nil {
+		return err
+	}
+
+	// Shared names would not resolve, so those prompts complete by ID.
+	collisions := prompt.Collisions(prompts)
+	for _, p := range prompts {
+		if _, ok := collisions[strings.ToLower(p.Name)]; ok {
+			fmt.Fprintln(out, p.ID)
+			continue
+		}
+		fmt.Fprintln(out, p.Name)
+	}
+	return nil
+}
+
+func commandNames() string {
+	names := make([]string, 0, len(completionCommands))
+	for _, cmd := range completionCommands {
+		names = append(names, cmd.name)
+	}
+	return strings.Join(names, " ")
+}
+
+func flagNames(flags []completionFlag) string {
+	names := make([]string, 0, len(flags))
+	for _, f := range flags {
+		names = append(names, "--"+f.name)
+	}
+	return strings.Join(names, " ")
+}
+
+func bashCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# bash completion for pm
+_pm() {
+    local cur prev cmd flags
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    if [[ ${COMP_CWORD} -eq 1 ]]; then
+        COMPREPLY=( $(compgen -W "`)
+	b.WriteString(commandNames() + " " + flagNames(completionCommands[0].allFlags()))
+	b.WriteString(`" -- "${cur}") )
+        return
+    fi
+
+    cmd="${COMP_WORDS[1]}"
+    case "${prev}" in
+`)
+	writeBashValueCases(&b)
+	b.WriteString(`    esac
+
+    case "${cmd}" in
+`)
+	for _, cmd := range completionCommands {
+		fmt.Fprintf(&b, "        %s) flags=%q ;;\n", cmd.name, flagNames(cmd.allFlags()))
+	}
+	b.WriteString(`        -*) cmd=pick; flags="` + flagNames(completionCommands[0].allFlags()) + `" ;;
+    esac
+
+    if [[ "${cur}" == -* ]]; then
+        COMPREPLY=( $(compgen -W "${flags}" -- "${cur}") )
+        return
+    fi
+
+    case "${cmd}" in
+`)
+	for _, cmd := range completionCommands {
+		switch {
+		case cmd.promptArgs:
+			fmt.Fprintf(&b, "        %s)\n            local IFS=$'\\n'\n            COMPREPLY=( $(compgen -W \"$(_pm_prompts)\" -- \"${cur}\") )\n            ;;\n", cmd.name)
+		case len(cmd.args) > 0:
+			fmt.Fprintf(&b, "        %s) COMPREPLY=( $(compgen -W %q -- \"${cur}\") ) ;;\n", cmd.name, strings.Join(cmd.args, " "))
+		}
+	}
+	b.WriteString(`    esac
+}
+
+# _pm_prompts lists prompt names from the --dir typed on the command line.
+_pm_prompts() {
+    local i dir=
+    for (( i = 1; i < COMP_CWORD; i++ )); do
+        case "${COMP_WORDS[i]}" in
+            --dir)
+                dir="${COMP_WORDS[i+1]}"
+                # COMP_WORDBREAKS splits --dir=value into three words.
+                [[ "${dir}" == "=" ]] && dir="${COMP_WORDS[i+2]}"
+                ;;
+        esac
+    done
+    pm ` + completeNamesCommand + ` ${dir:+--dir "${dir}"} 2>/dev/null
+}
+complete -F _pm pm
+`)
+	return b.String()
+}
+
+func writeBashValueCases(b *strings.Builder) {
+	seen := make(map[string]struct{})
+	for _, cmd := range completionCommands {
+		for _, f := range cmd.allFlags() {
+			if _, ok := seen[f.name]; ok || !f.takesValue {
+				continue
+			}
+			seen[f.name] = struct{}{}
+			switch {
+			case f.dir:
+				fmt.Fprintf(b, "        --%s) COMPREPLY=( $(compgen -d -- \"${cur}\") ); return ;;\n", f.name)
+			case len(f.values) > 0:
+				fmt.Fprintf(b, "        --%s) COMPREPLY=( $(compgen -W %q -- \"${cur}\") ); return ;;\n", f.name, strings.Join(f.values, " "))
+			default:
+				fmt.Fprintf(b, "        --%s) return ;;\n", f.name)
+			}
+		}
+	}
+}
+
+func zshCompletion() string {
+	var b strings.Builder
+	b.WriteString(`#compdef pm
+# zsh completion for pm
+_pm() {
+    local -a commands
+    commands=(
+`)
+	for _, cmd := range completionCommands {
+		fmt.Fprintf(&b, "        %q\n", cmd.name+":"+cmd.description)
+	}
+	b.WriteString(`    )
+
+    if (( CURRENT == 2 )); then
+        _describe 'command' commands
+        return
+    fi
+
+    local cmd="${words[2]}"
+    shift words
+    (( CURRENT-- ))
+
+    case "${cmd}" in
+`)
+	for _, cmd := range completionCommands {
+		fmt.Fprintf(&b, "        %s)\n            _arguments -s", cmd.name)
+		for _, f := range cmd.allFlags() {
+			fmt.Fprintf(&b, " \\\n                %s", zshFlagSpec(f))
+		}
+		switch {
+		case cmd.promptArgs:
+			b.WriteString(" \\\n                '*:prompt:_pm_prompts'")
+		case len(cmd.args) > 0:
+			fmt.Fprintf(&b, " \\\n                '1:argument:(%s)'", strings.Join(cmd.args, " "))
+		}
+		b.WriteString("\n            ;;\n")
+	}
+	b.WriteString(`    esac
+}
+
+# _pm_prompts completes prompt names from the --dir typed on the command line.
+_pm_prompts() {
+    local -a names dir
+    local i
+    for (( i = 1; i < CURRENT; i++ )); do
+        case "${words[i]}" in
+            --dir) dir=(--dir "${(Q)words[i+1]}") ;;
+            --dir=*) dir=(--dir "${(Q)words[i]#--dir=}") ;;
+        esac
+    done
+    names=("${(@f)$(pm ` + completeNamesCommand + ` "${dir[@]}" 2>/dev/null)}")
+    compadd -a names
+}
+
+if [[ "${funcstack[1]}" == "_pm" ]]; then
+    _pm "$@"
+else
+    compdef _pm pm
+fi
+`)
+	return b.String()
+}
+
+func zshFlagSpec(f completionFlag) string {
+	spec := "--" + f.name + "[" + f.description + "]"
+	if f.repeatable {
+		spec = "*" + spec
+	}
+	switch {
+	case f.dir:
+		spec += ":directory:_files -/"
+	case len(f.values) > 0:
+		spec += ":" + f.name + ":(" + strings.Join(f.values, " ") + ")"
+	case f.takesValue:
+		spec += ":" + f.name + ":"
+	}
+	return "'" + spec + "'"
+}
+
+func fishCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# fish completion for pm
+complete -c pm -f
+
+# __pm_prompts lists prompt names from the --dir typed on the command line.
+function __pm_prompts
+    set -l tokens (commandline -opc)
+    set -l dir
+    for i in (seq (count $tokens))
+        switch $tokens[$i]
+            case --dir
+                if test $i -lt (count $tokens)
+                    set dir --dir $tokens[(math $i + 1)]
+                end
+            case '--dir=*'
+                set dir --dir (string replace -- --dir= '' $tokens[$i])
+        end
+    end
+    pm ` + completeNamesCommand + ` $dir 2>/dev/null
+end
+`)
+	for _, cmd := range completionCommands {
+		fmt.Fprintf(&b, "complete -c pm -n __fish_use_subcommand -a %s -d %s\n", cmd.name, fishQuote(cmd.description))
+	}
+	for _, cmd := range completionCommands {
+		condition := fishQuote("__fish_seen_subcommand_from " + cmd.name)
+		for _, f := range cmd.allFlags() {
+			fmt.Fprintf(&b, "complete -c pm -n %s -l %s -d %s", condition, f.name, fishQuote(f.description))
+			switch {
+			case f.dir:
+				b.WriteString(" -r -a '(__fish_complete_directories)'")
+			case len(f.values) > 0:
+				fmt.Fprintf(&b, " -x -a %s", fishQuote(strings.Join(f.values, " ")))
+			case f.takesValue:
+				b.WriteString(" -x")
+			}
+			b.WriteByte('\n')
+		}
+		switch {
+		case cmd.promptArgs:
+			fmt.Fprintf(&b, "complete -c pm -n %s -a '(__pm_prompts)'\n", condition)
+		case len(cmd.args) > 0:
+			fmt.Fprintf(&b, "complete -c pm -n %s -a %s\n", condition, fishQuote(strings.Join(cmd.args, " ")))
+		}
+	}
+	return b.String()
+}
+
+func fishQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
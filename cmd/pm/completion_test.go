@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRunCompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := runCompletion([]string{shell}, &out); err != nil {
+				t.Fatalf("runCompletion(%s) error = %v", shell, err)
+			}
+
+			script := out.String()
+			for _, want := range []string{"pick", "search", "ls", "cat", "mesh", "format", "var", completeNamesCommand} {
+				if !strings.Contains(script, want) {
+					t.Fatalf("expected %s script to mention %q", shell, want)
+				}
+			}
+
+			if path, err := exec.LookPath(shell); err == nil {
+				check := exec.Command(path, "-n")
+				check.Stdin = strings.NewReader(script)
+				if output, err := check.CombinedOutput(); err != nil {
+					t.Fatalf("%s rejected the script: %v\n%s", shell, err, output)
+				}
+			}
+		})
+	}
+}
+
+func TestCompletionOffersGlobalFlagsEverywhere(t *testing.T) {
+	for _, cmd := range completionCommands {
+		names := flagNames(cmd.allFlags())
+		for _, global := range []string{"--config", "--strict", "--rank"} {
+			if strings.Count(names, global) != 1 {
+				t.Fatalf("expected %s to offer %s once, got %q", cmd.name, global, names)
+			}
+		}
+	}
+}
+
+func TestCompletionForwardsTypedDir(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var out bytes.Buffer
+		if err := runCompletion([]string{shell}, &out); err != nil {
+			t.Fatalf("runCompletion(%s) error = %v", shell, err)
+		}
+		if !strings.Contains(out.String(), "--dir") || strings.Contains(out.String(), "$(pm "+completeNamesCommand+" 2>") {
+			t.Fatalf("expected the %s script to pass the typed --dir to %s", shell, completeNamesCommand)
+		}
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	// Bash splits --dir=value at the "=" when completing.
+	script := bashCompletion() + `
+pm() { echo "$*"; }
+COMP_WORDS=(pm cat --dir = team/prompts re)
+COMP_CWORD=5
+_pm_prompts
+COMP_WORDS=(pm cat --dir team re)
+COMP_CWORD=4
+_pm_prompts
+COMP_WORDS=(pm cat re)
+COMP_CWORD=2
+_pm_prompts
+`
+	got, err := exec.Command(bash, "-c", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash error = %v\n%s", err, got)
+	}
+	want := completeNamesCommand + " --dir team/prompts\n" + completeNamesCommand + " --dir team\n" + completeNamesCommand + "\n"
+	if string(got) != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestZshRepeatableFlags(t *testing.T) {
+	script := zshCompletion()
+	if !strings.Contains(script, "'*--var[Template variable as key=value]:var:'") {
+		t.Fatal("expected zsh to offer --var more than once")
+	}
+	if strings.Contains(script, "'*--dir[") {
+		t.Fatal("expected --dir to be offered once")
+	}
+}
+
+func TestRunCompletionRejectsUnknownShell(t *testing.T) {
+	if err := runCompletion([]string{"tcsh"}, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected error for unsupported shell")
+	}
+}
+
+func TestRunCompleteNamesListsPrompts(t *testing.T) {
+	ctx := testAppContext()
+	var out bytes.Buffer
+
+	if err := runCompleteNames(ctx, nil, &out); err != nil {
+		t.Fatalf("runCompleteNames error = %v", err)
+	}
+
+	names := strings.Fields(out.String())
+	if strings.Join(names, ",") != "brainstorm,code-review,product-brief" {
+		t.Fatalf("unexpected names %v", names)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hzionn/prompt-manager-cli/internal/config"
+)
+
+func runConfig(ctx appContext, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("config requires a subcommand: show, get, set, path or validate")
+	}
+
+	switch args[0] {
+	case "show":
+		return runConfigShow(ctx, args[1:], out)
+	case "get":
+		return runConfigGet(ctx, args[1:], out)
+	case "set":
+		return runConfigSet(ctx, args[1:], out)
+	case "path":
+		return runConfigPath(ctx, out)
+	case "validate":
+		return runConfigValidate(ctx, args[1:], out)
+	default:
+		return fmt.Errorf("unknown config subcommand %q", args[0])
+	}
+}
+
+type settingRecord struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+	Origin string `json:"origin,omitempty"`
+}
+
+// runConfigShow prints every effective setting and where it came from. --dir
+// shows the directories a command given the same flag would scan.
+func runConfigShow(ctx appContext, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var dirFlag string
+	var format string
+	fs.StringVar(&dirFlag, "dir", "", "Prompt directories (comma separated)")
+	fs.StringVar(&format, "format", formatText, "Output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateFormat(format, formatText, formatJSON); err != nil {
+		return err
+	}
+
+	settings := ctx.settings
+	sources := make(map[string]config.Source, len(ctx.sources))
+	for key, source := range ctx.sources {
+		sources[key] = source
+	}
+	if dirFlag != "" {
+		settings.DefaultDirs = splitAndTrim(dirFlag)
+		sources["default_dir"] = config.Source{Kind: config.SourceFlag, Name: "--dir"}
+	}
+
+	records := make([]settingRecord, 0, len(config.Keys))
+	for _, key := range config.Keys {
+		value, err := settings.Get(key)
+		if err != nil {
+			return err
+		}
+		source, ok := sources[key]
+		if !ok {
+			source = config.Source{Kind: config.SourceDefault}
+		}
+		records = append(records, settingRecord{Key: key, Value: value, Source: source.Kind, Origin: source.Name})
+	}
+
+	for _, source := range settings.Sources {
+		origin := sources["sources"]
+		records = append(records, settingRecord{Key: "sources." + source.Name, Value: newSourceRecord(source), Source: origin.Kind, Origin: origin.Name})
+	}
+
+	if format == formatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+
+	for _, record := range records {
+		value, err := encodeSetting(record.Value)
+		if err != nil {
+			return err
+		}
+		source := config.Source{Kind: record.Source, Name: record.Origin}
+		fmt.Fprintf(out, "%s = %s  # %s\n", record.Key, value, source)
+	}
+	return nil
+}
+
+// sourceRecord is a [[sources]] table as shown by pm config show.
+type sourceRecord struct {
+	Path           string   `json:"path"`
+	Extensions     []string `json:"extensions,omitempty"`
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+	MaxFileSizeKB  int      `json:"max_file_size_kb,omitempty"`
+	ReadOnly       bool     `json:"read_only,omitempty"`
+	Weight         float64  `json:"weight,omitempty"`
+}
+
+func newSourceRecord(s config.SourceSettings) sourceRecord {
+	return sourceRecord{
+		Path:           s.Path,
+		Extensions:     s.Extensions,
+		IgnorePatterns: s.IgnorePatterns,
+		MaxFileSizeKB:  s.MaxFileSizeKB,
+		ReadOnly:       s.ReadOnly,
+		Weight:         s.Weight,
+	}
+}
+
+// encodeSetting formats a value for pm config show, writing sources as inline
+// tables of the fields they set.
+func encodeSetting(value any) (string, error) {
+	source, ok := value.(sourceRecord)
+	if !ok {
+		return config.EncodeValue(value)
+	}
+
+	fields := []struct {
+		key   string
+		value any
+		set   bool
+	}{
+		{"path", source.Path, true},
+		{"extensions", source.Extensions, len(source.Extensions) > 0},
+		{"ignore_patterns", source.IgnorePatterns, len(source.IgnorePatterns) > 0},
+		{"max_file_size_kb", source.MaxFileSizeKB, source.MaxFileSizeKB > 0},
+		{"read_only", source.ReadOnly, source.ReadOnly},
+		{"weight", source.Weight, source.Weight > 0},
+	}
+	var parts []string
+	for _, field := range fields {
+		if !field.set {
+			continue
+		}
+		encoded, err := config.EncodeValue(field.value)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, field.key+" = "+encoded)
+	}
+	return "{" + strings.Join(parts, ", ") + "}", nil
+}
+
+// runConfigGet prints the effective value of one key: list values one per
+// line, everything else as is.
+func runConfigGet(ctx appContext, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("config get requires a key")
+	}
+
+	value, err := ctx.settings.Get(args[0])
+	if err != nil {
+		return err
+	}
+	if values, ok := value.([]string); ok {
+		for _, v := range values {
+			fmt.Fprintln(out, v)
+		}
+		return nil
+	}
+	_, err = fmt.Fprintln(out, value)
+	return err
+}
+
+// runConfigSet writes a key to the user settings file, or the file given with
+// --file, creating it when needed.
+func runConfigSet(ctx appContext, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("config set", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var path string
+	fs.StringVar(&path, "file", "", "Settings file to edit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("config set requires a key and a value")
+	}
+
+	if path == "" {
+		path = ctx.userConfig
+	}
+	if path == "" {
+		path = config.DefaultPath()
+	}
+	if path == "" {
+		return errors.New("cannot determine where to write settings; use --file")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	perm := os.FileMode(0o644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		perm = info.Mode().Perm()
+	}
+
+	updated, err := config.Set(data, fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, updated, perm); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, path)
+	return nil
+}
+
+// runConfigPath prints the settings files in use, in the order they are merged.
+func runConfigPath(ctx appContext, out io.Writer) error {
+	if len(ctx.configFiles) == 0 {
+		return fmt.Errorf("no settings file found; pm config set creates %s", config.DefaultPath())
+	}
+	for _, path := range ctx.configFiles {
+		fmt.Fprintln(out, path)
+	}
+	return nil
+}
+
+// runConfigValidate checks the settings files in use, or the files given as
+// arguments, and prints every issue found.
+func runConfigValidate(ctx appContext, args []string, out io.Writer) error {
+	files := ctx.configFiles
+	if len(args) > 0 {
+		files = args
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(out, "no settings file found; using the built-in defaults")
+		return nil
+	}
+
+	_, issues, err := config.LoadStrict(files...)
+	for _, issue := range issues {
+		fmt.Fprintln(out, issue)
+	}
+
+	var validation *config.ValidationError
+	if errors.As(err, &validation) {
+		return fmt.Errorf("settings are invalid: %d error(s)", len(validation.Issues))
+	}
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		fmt.Fprintf(out, "%s: ok\n", strings.Join(files, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hzionn/prompt-manager-cli/internal/config"
+)
+
+func TestRunConfigValidateReportsIssues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	if err := os.WriteFile(path, []byte("default_dir = [\"prompts\", 1]\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	err := runConfig(testAppContext(), []string{"validate", path}, &out)
+	if err == nil {
+		t.Fatal("expected validate to fail")
+	}
+	if !strings.Contains(out.String(), path+":1:1: error: default_dir[1] must be a string") {
+		t.Fatalf("expected positioned error, got %q", out.String())
+	}
+}
+
+func TestRunConfigValidateAcceptsValidFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.toml")
+	content := "default_dir = \"" + filepath.ToSlash(dir) + "\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	ctx := testAppContext()
+	ctx.configFiles = []string{path}
+
+	var out bytes.Buffer
+	if err := runConfig(ctx, []string{"validate"}, &out); err != nil {
+		t.Fatalf("runConfig error = %v", err)
+	}
+	if strings.TrimSpace(out.String()) != path+": ok" {
+		t.Fatalf("expected ok, got %q", out.String())
+	}
+}
+
+func TestRunConfigShowReportsSources(t *testing.T) {
+	ctx := testAppContext()
+	ctx.sources = map[string]config.Source{
+		"ui.truncate_length": {Kind: config.SourceFile, Name: "settings.toml"},
+	}
+
+	var out bytes.Buffer
+	if err := runConfig(ctx, []string{"show", "--dir", "a,b"}, &out); err != nil {
+		t.Fatalf("runConfig error = %v", err)
+	}
+
+	for _, want := range []string{
+		"default_dir = ['a', 'b']  # flag --dir\n",
+		"ui.truncate_length = 0  # file settings.toml\n",
+		"fuzzy_search.max_results = 20  # default\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunConfigSetAndGet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pm", "settings.toml")
+	ctx := testAppContext()
+	ctx.userConfig = path
+
+	var out bytes.Buffer
+	if err := runConfig(ctx, []string{"set", "fuzzy_search.max_results", "7"}, &out); err != nil {
+		t.Fatalf("runConfig set error = %v", err)
+	}
+	if strings.TrimSpace(out.String()) != path {
+		t.Fatalf("expected the edited path, got %q", out.String())
+	}
+
+	settings := config.Load(path)
+	if settings.FuzzySearch.MaxResults != 7 {
+		t.Fatalf("expected max_results 7 in %s, got %d", path, settings.FuzzySearch.MaxResults)
+	}
+
+	ctx.settings = settings
+	out.Reset()
+	if err := runConfig(ctx, []string{"get", "fuzzy_search.max_results"}, &out); err != nil {
+		t.Fatalf("runConfig get error = %v", err)
+	}
+	if out.String() != "7\n" {
+		t.Fatalf("expected 7, got %q", out.String())
+	}
+}
+
+func TestRunConfigPathListsFiles(t *testing.T) {
+	ctx := testAppContext()
+	ctx.configFiles = []string{"user.toml", ".pm.toml"}
+
+	var out bytes.Buffer
+	if err := runConfig(ctx, []string{"path"}, &out); err != nil {
+		t.Fatalf("runConfig error = %v", err)
+	}
+	if out.String() != "user.toml\n.pm.toml\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/search"
+)
+
+// Output formats accepted by --format.
+const (
+	formatText   = "text"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatTSV    = "tsv"
+)
+
+// promptRecord is the machine-readable form of a prompt written by --format
+// json, ndjson and tsv. The JSON field names are part of pm's interface and
+// must stay stable; they are documented in the README.
+type promptRecord struct {
+	Name          string             `json:"name"`
+	ID            string             `json:"id"`
+	Path          string             `json:"path"`
+	Tags          []string           `json:"tags"`
+	FrontMatter   map[string]any     `json:"front_matter"`
+	Variables     []prompt.Variable  `json:"variables"`
+	Content       *string            `json:"content,omitempty"`
+	ContentLength int                `json:"content_length"`
+	Score         *float64           `json:"score,omitempty"`
+	Explanation   *explanationRecord `json:"explanation,omitempty"`
+	Dropped       bool               `json:"dropped,omitempty"`
+}
+
+// explanationRecord is the score breakdown written by pm search --explain.
+type explanationRecord struct {
+	Components   []componentRecord `json:"components"`
+	Sum          float64           `json:"sum"`
+	Cutoff       float64           `json:"cutoff"`
+	SourceWeight float64           `json:"source_weight"`
+	Word         string            `json:"word,omitempty"`
+}
+
+type componentRecord struct {
+	Field    string  `json:"field"`
+	Score    float64 `json:"score"`
+	Weight   float64 `json:"weight"`
+	Weighted float64 `json:"weighted"`
+}
+
+func newPromptRecord(p prompt.Prompt) promptRecord {
+	record := promptRecord{
+		Name:          p.Name,
+		ID:            p.ID,
+		Path:          p.Path,
+		Tags:          p.Tags,
+		FrontMatter:   p.FrontMatter,
+		Variables:     p.TemplateVariables(),
+		ContentLength: len(p.Content),
+	}
+	if record.Tags == nil {
+		record.Tags = []string{}
+	}
+	if record.FrontMatter == nil {
+		record.FrontMatter = map[string]any{}
+	}
+	if record.Variables == nil {
+		record.Variables = []prompt.Variable{}
+	}
+	return record
+}
+
+func (r promptRecord) withContent(content string) promptRecord {
+	r.Content = &content
+	r.ContentLength = len(content)
+	return r
+}
+
+func (r promptRecord) withScore(score float64) promptRecord {
+	r.Score = &score
+	return r
+}
+
+// withExplanation adds the score breakdown of e, if any, and marks records of
+// prompts that the cutoff dropped.
+func (r promptRecord) withExplanation(e *search.Explanation, dropped bool) promptRecord {
+	r.Dropped = dropped
+	if e == nil {
+		return r
+	}
+	record := &explanationRecord{
+		Components:   make([]componentRecord, 0, len(e.Components)),
+		Sum:          e.Sum,
+		Cutoff:       e.Cutoff,
+		SourceWeight: e.SourceWeight,
+		Word:         e.Word,
+	}
+	for _, c := range e.Components {
+		record.Components = append(record.Components, componentRecord{Field: c.Field, Score: c.Score, Weight: c.Weight, Weighted: c.Weighted()})
+	}
+	r.Explanation = record
+	return r
+}
+
+func validateFormat(format string, allowed ...string) error {
+	for _, candidate := range allowed {
+		if format == candidate {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported format %q (use %s)", format, strings.Join(allowed, ", "))
+}
+
+// writeRecords writes records in a machine-readable format. JSON output is a
+// single array, NDJSON one object per line and TSV one row per record with the
+// columns name, path, tags, then score and content when present.
+func writeRecords(out io.Writer, format string, records []promptRecord) error {
+	switch format {
+	case formatJSON:
+		if records == nil {
+			records = []promptRecord{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case formatNDJSON:
+		enc := json.NewEncoder(out)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatTSV:
+		for _, r := range records {
+			columns := []string{r.Name, r.Path, strings.Join(r.Tags, ",")}
+			if r.Score != nil {
+				columns = append(columns, strconv.FormatFloat(*r.Score, 'f', 4, 64))
+			}
+			if r.Content != nil {
+				columns = append(columns, *r.Content)
+			}
+			for i, column := range columns {
+				columns[i] = escapeTSV(column)
+			}
+			if _, err := fmt.Fprintln(out, strings.Join(columns, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+// diagnosticRecord is the machine-readable form of a load diagnostic written
+// by pm ls --diagnostics.
+type diagnosticRecord struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// writeDiagnostics writes diagnostics one per line as "path:line: kind:
+// message" in text format, or as records with the columns path, line, kind
+// and message otherwise.
+func writeDiagnostics(out io.Writer, format string, diagnostics []prompt.Diagnostic) error {
+	records := make([]diagnosticRecord, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		records = append(records, diagnosticRecord{Path: d.Path, Line: d.Line, Kind: string(d.Kind), Message: d.Message})
+	}
+
+	switch format {
+	case formatText:
+		for _, d := range diagnostics {
+			if _, err := fmt.Fprintln(out, d.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case formatNDJSON:
+		enc := json.NewEncoder(out)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatTSV:
+		for _, r := range records {
+			columns := []string{r.Path, strconv.Itoa(r.Line), r.Kind, r.Message}
+			for i, column := range columns {
+				columns[i] = escapeTSV(column)
+			}
+			if _, err := fmt.Fprintln(out, strings.Join(columns, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+// writeRecord writes a single record; JSON output is an object rather than an array.
+func writeRecord(out io.Writer, format string, record promptRecord) error {
+	if format == formatJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(record)
+	}
+	return writeRecords(out, format, []promptRecord{record})
+}
+
+var tsvEscaper = strings.NewReplacer("\\", `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func escapeTSV(value string) string {
+	return tsvEscaper.Replace(value)
+}
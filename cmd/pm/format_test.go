@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRunSearchJSONIncludesScores(t *testing.T) {
+	ctx := testAppContext()
+	var out bytes.Buffer
+
+	if err := runSearch(ctx, []string{"--format", "json", "product"}, nil, &out); err != nil {
+		t.Fatalf("runSearch error = %v", err)
+	}
+
+	var records []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &records); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(records) == 0 || records[0]["name"] != "product-brief" {
+		t.Fatalf("unexpected records %v", records)
+	}
+
+	first := records[0]
+	for _, key := range []string{"path", "tags", "front_matter", "variables", "content_length", "score"} {
+		if _, ok := first[key]; !ok {
+			t.Fatalf("expected field %q in %v", key, first)
+		}
+	}
+	if _, ok := first["content"]; ok {
+		t.Fatal("expected search output to omit content")
+	}
+}
+
+func TestRunSearchExplainJSONMarksDropped(t *testing.T) {
+	ctx := testAppContext()
+	var out bytes.Buffer
+
+	if err := runSearch(ctx, []string{"--explain", "--format", "ndjson", "code brief"}, nil, &out); err != nil {
+		t.Fatalf("runSearch error = %v", err)
+	}
+
+	var record struct {
+		Name        string `json:"name"`
+		Dropped     bool   `json:"dropped"`
+		Explanation struct {
+			Components []struct {
+				Field  string  `json:"field"`
+				Weight float64 `json:"weight"`
+			} `json:"components"`
+			Cutoff float64 `json:"cutoff"`
+			Word   string  `json:"word"`
+		} `json:"explanation"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if record.Name != "product-brief" || !record.Dropped || record.Explanation.Word != "code" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if len(record.Explanation.Components) != 5 || record.Explanation.Components[0].Field != "name" || record.Explanation.Cutoff != 0.25 {
+		t.Fatalf("unexpected explanation %+v", record.Explanation)
+	}
+}
+
+func TestRunSearchWithoutMatches(t *testing.T) {
+	ctx := testAppContext()
+
+	for format, want := range map[string]string{formatJSON: "[]\n", formatNDJSON: "", formatTSV: ""} {
+		var out bytes.Buffer
+		if err := runSearch(ctx, []string{"--format", format, "zzzqqq"}, nil, &out); err != nil {
+			t.Fatalf("%s: runSearch error = %v", format, err)
+		}
+		if out.String() != want {
+			t.Fatalf("%s: expected %q, got %q", format, want, out.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runSearch(ctx, []string{"zzzqqq"}, nil, &out); err == nil {
+		t.Fatal("expected text output to report that nothing matched")
+	}
+}
+
+func TestRunListNDJSON(t *testing.T) {
+	ctx := testAppContext()
+	var out bytes.Buffer
+
+	if err := runList(ctx, []string{"--format", "ndjson"}, &out); err != nil {
+		t.Fatalf("runList error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected one line per prompt, got %q", out.String())
+	}
+	for _, line := range lines {
+		var record promptRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", line, err)
+		}
+		if record.Tags == nil || record.FrontMatter == nil {
+			t.Fatalf("expected empty collections instead of null, got %q", line)
+		}
+	}
+}
+
+func TestRunCatJSONIncludesContent(t *testing.T) {
+	ctx := testAppContext()
+	var out bytes.Buffer
+
+	if err := runCat(ctx, []string{"--format", "json", "code-review"}, &out); err != nil {
+		t.Fatalf("runCat error = %v", err)
+	}
+
+	var record promptRecord
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if record.Content == nil || !strings.Contains(*record.Content, "Code Review") || record.ContentLength != len(*record.Content) {
+		t.Fatalf("unexpected record %+v", record)
+	}
+}
+
+func TestRunCatTSVEscapesContent(t *testing.T) {
+	ctx := testAppContext()
+	var out bytes.Buffer
+
+	if err := runCat(ctx, []string{"--format", "tsv", "code-review"}, &out); err != nil {
+		t.Fatalf("runCat error = %v", err)
+	}
+
+	columns := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\t")
+	if len(columns) != 4 || columns[0] != "code-review" || !strings.Contains(columns[3], `Checklist\n`) {
+		t.Fatalf("unexpected TSV row %q", out.String())
+	}
+}
+
+func TestRunListRejectsUnknownFormat(t *testing.T) {
+	ctx := testAppContext()
+	if err := runList(ctx, []string{"--format", "yaml"}, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected unsupported format error")
+	}
+}
@@ -6,13 +6,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"golang.org/x/term"
 
 	"github.com/hzionn/prompt-manager-cli/internal/clipboard"
 	"github.com/hzionn/prompt-manager-cli/internal/config"
+	"github.com/hzionn/prompt-manager-cli/internal/editor"
 	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/ranking"
 	"github.com/hzionn/prompt-manager-cli/internal/search"
 	"github.com/hzionn/prompt-manager-cli/internal/ui"
 )
@@ -25,29 +28,83 @@ func main() {
 }
 
 type appContext struct {
-	settings   config.Settings
-	promptOpts prompt.Options
-	searchOpts search.Options
+	settings    config.Settings
+	sources     map[string]config.Source
+	userConfig  string   // the located user settings file, if any
+	configFiles []string // every settings file loaded, in merge order
+	promptOpts  prompt.Options
+	searchOpts  search.Options
 }
 
-func newAppContext() appContext {
-	settings := config.Load("config/settings.toml")
+// newAppContext loads the settings from the file chosen by config.Locate with
+// the nearest project file merged on top. An explicit path that cannot be
+// read is an error; the standard locations are optional.
+func newAppContext(configPath string) (appContext, error) {
+	var files []string
+	userConfig := config.Locate(configPath)
+	if path := userConfig; path != "" {
+		if configPath != "" || os.Getenv("PM_CONFIG") != "" {
+			if _, err := os.Stat(path); err != nil {
+				return appContext{}, fmt.Errorf("config file: %w", err)
+			}
+		}
+		files = append(files, path)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if project := config.FindProjectFile(cwd); project != "" {
+			files = append(files, project)
+		}
+	}
+
+	settings, sources, issues := config.LoadWithSources(files...)
+	for _, issue := range issues {
+		// File problems are left to pm config validate; a bad override is
+		// easy to miss otherwise because it is silently ignored.
+		if issue.Env != "" {
+			fmt.Fprintf(os.Stderr, "pm: ignoring $%s: %s\n", issue.Env, issue.Message)
+		}
+	}
 	maxBytes := int64(settings.FileSystem.MaxFileSizeKB) * 1024
 	return appContext{
-		settings: settings,
+		settings:    settings,
+		sources:     sources,
+		userConfig:  userConfig,
+		configFiles: files,
 		promptOpts: prompt.Options{
 			Extensions:     settings.FileSystem.Extensions,
 			IgnorePatterns: settings.FileSystem.IgnorePatterns,
 			MaxFileSize:    maxBytes,
+			CacheDir:       settings.CacheDir,
+			Workers:        settings.FileSystem.Workers,
+			FollowSymlinks: settings.FileSystem.FollowSymlinks,
 		},
 		searchOpts: search.Options{
 			MaxResults: settings.FuzzySearch.MaxResults,
+			Ranking:    settings.FuzzySearch.Ranking(),
 		},
-	}
+	}, nil
 }
 
 func run(args []string, in io.Reader, out io.Writer) error {
-	ctx := newAppContext()
+	configPath, args, err := extractConfigFlag(args)
+	if err != nil {
+		return err
+	}
+	strict, args := extractStrictFlag(args)
+	rank, args, err := extractValueFlag(args, "rank")
+	if err != nil {
+		return err
+	}
+	ctx, err := newAppContext(configPath)
+	if err != nil {
+		return err
+	}
+	ctx.promptOpts.Strict = strict
+	if rank != "" {
+		if err := applyRankFlag(&ctx, rank); err != nil {
+			return err
+		}
+	}
 	if len(args) == 0 {
 		return runPick(ctx, []string{}, in, out)
 	}
@@ -63,6 +120,16 @@ func run(args []string, in io.Reader, out io.Writer) error {
 		return runCat(ctx, args[1:], out)
 	case "mesh":
 		return runMesh(ctx, args[1:], in, out)
+	case "new":
+		return runNew(ctx, args[1:], out)
+	case "edit":
+		return runEdit(ctx, args[1:])
+	case "completion":
+		return runCompletion(args[1:], out)
+	case "config":
+		return runConfig(ctx, args[1:], out)
+	case completeNamesCommand:
+		return runCompleteNames(ctx, args[1:], out)
 	case "--help", "-h", "help":
 		printUsage(out)
 		return nil
@@ -71,8 +138,43 @@ func run(args []string, in io.Reader, out io.Writer) error {
 			return runPick(ctx, args, in, out)
 		}
 		query := strings.Join(args, " ")
-		return runPickWithQuery(ctx, query, "", false, out)
+		return runPickWithQuery(ctx, query, "", false, nil, out)
+	}
+}
+
+// extractConfigFlag removes the global --config flag from args, wherever it
+// appears before a "--" terminator, and returns its value.
+func extractConfigFlag(args []string) (string, []string, error) {
+	return extractValueFlag(args, "config")
+}
+
+// extractValueFlag removes the global flag name and its value from args,
+// wherever it appears before a "--" terminator, and returns the value.
+func extractValueFlag(args []string, name string) (string, []string, error) {
+	var value string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		switch {
+		case arg == "--"+name || arg == "-"+name:
+			if i+1 >= len(args) {
+				return "", nil, errors.New("flag needs an argument: --" + name)
+			}
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--"+name+"="):
+			value = strings.TrimPrefix(arg, "--"+name+"=")
+		case strings.HasPrefix(arg, "-"+name+"="):
+			value = strings.TrimPrefix(arg, "-"+name+"=")
+		default:
+			rest = append(rest, arg)
+		}
 	}
+	return value, rest, nil
 }
 
 func runPick(ctx appContext, args []string, in io.Reader, out io.Writer) error {
@@ -83,11 +185,13 @@ func runPick(ctx appContext, args []string, in io.Reader, out io.Writer) error {
 	var query string
 	var interactive bool
 	var copyToClipboard bool
+	vars := varFlags{}
 
 	fs.StringVar(&dirFlag, "dir", "", "Prompt directories (comma separated)")
 	fs.StringVar(&query, "query", "", "Query to select a prompt non-interactively")
 	fs.BoolVar(&interactive, "interactive", false, "Force interactive selection")
 	fs.BoolVar(&copyToClipboard, "copy", false, "Copy the chosen prompt to the clipboard")
+	fs.Var(vars, "var", "Template variable as key=value (repeatable)")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -98,33 +202,47 @@ func runPick(ctx appContext, args []string, in io.Reader, out io.Writer) error {
 	}
 
 	if query != "" {
-		return runPickWithQuery(ctx, query, dirFlag, copyToClipboard, out)
+		return runPickWithQuery(ctx, query, dirFlag, copyToClipboard, vars, out)
 	}
 
 	if !interactive && fs.NArg() > 0 {
 		// Allow positional query arguments.
 		query = strings.Join(fs.Args(), " ")
-		return runPickWithQuery(ctx, query, dirFlag, copyToClipboard, out)
+		return runPickWithQuery(ctx, query, dirFlag, copyToClipboard, vars, out)
 	}
 
-	return runPickInteractive(ctx, dirFlag, copyToClipboard, in, out)
+	return runPickInteractive(ctx, dirFlag, copyToClipboard, vars, in, out)
 }
 
-func runPickWithQuery(ctx appContext, query, dirFlag string, copyToClipboard bool, out io.Writer) error {
+func runPickWithQuery(ctx appContext, query, dirFlag string, copyToClipboard bool, vars map[string]string, out io.Writer) error {
 	prompts, err := loadPrompts(ctx, dirFlag)
 	if err != nil {
 		return err
 	}
 
-	results := search.Search(prompts, query, ctx.searchOpts)
-	if len(results) == 0 {
-		return fmt.Errorf("no prompts found for query %q", query)
+	// An ID, relative path or name picks its prompt directly; anything else
+	// is searched for.
+	chosen, err := prompt.Resolve(prompts, query)
+	var notFound *prompt.NotFoundError
+	if errors.As(err, &notFound) {
+		results := search.Search(prompts, query, ctx.searchOpts)
+		if len(results) == 0 {
+			return fmt.Errorf("no prompts found for query %q", query)
+		}
+		chosen = results[0]
+	} else if err != nil {
+		return err
 	}
 
-	return outputPrompt(results[0].Content, copyToClipboard, out)
+	content, err := renderPrompt(chosen, prompts, vars)
+	if err != nil {
+		return err
+	}
+
+	return outputPrompt(content, copyToClipboard, out)
 }
 
-func runPickInteractive(ctx appContext, dirFlag string, copyToClipboard bool, in io.Reader, out io.Writer) error {
+func runPickInteractive(ctx appContext, dirFlag string, copyToClipboard bool, vars map[string]string, in io.Reader, out io.Writer) error {
 	prompts, err := loadPrompts(ctx, dirFlag)
 	if err != nil {
 		return err
@@ -136,32 +254,49 @@ func runPickInteractive(ctx appContext, dirFlag string, copyToClipboard bool, in
 
 	sorted := search.Search(prompts, "", search.Options{})
 	// Use stderr for the interactive UI to keep stdout clean for the prompt output
-	selected, err := ui.SelectPromptWithQuery(sorted, "", search.Options{}, in, os.Stderr)
+	selection, err := ui.SelectAndFill(sorted, "", ctx.searchOpts, vars, in, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	content, err := renderPrompt(selection.Prompt, prompts, selection.Values)
 	if err != nil {
 		return err
 	}
 
-	return outputPrompt(selected.Content, copyToClipboard, out)
+	return outputPrompt(content, copyToClipboard, out)
 }
 
-type fdReader interface {
+type fdFile interface {
 	Fd() uintptr
 }
 
-type terminalAwareReader interface {
+type terminalAware interface {
 	IsTerminal() bool
 }
 
+// isTerminalOutput reports whether out is a terminal, so that output meant
+// for people can be decorated without affecting pipes.
+func isTerminalOutput(out io.Writer) bool {
+	if aware, ok := out.(terminalAware); ok {
+		return aware.IsTerminal()
+	}
+	if fd, ok := out.(fdFile); ok {
+		return term.IsTerminal(int(fd.Fd()))
+	}
+	return false
+}
+
 func shouldReadFromInput(in io.Reader) bool {
 	if in == nil {
 		return false
 	}
 
-	if aware, ok := in.(terminalAwareReader); ok {
+	if aware, ok := in.(terminalAware); ok {
 		return !aware.IsTerminal()
 	}
 
-	if fd, ok := in.(fdReader); ok {
+	if fd, ok := in.(fdFile); ok {
 		return !term.IsTerminal(int(fd.Fd()))
 	}
 
@@ -175,13 +310,27 @@ func runSearch(ctx appContext, args []string, in io.Reader, out io.Writer) error
 	var dirFlag string
 	var limit int
 	var interactive bool
+	var explain bool
+	var format string
 	fs.StringVar(&dirFlag, "dir", "", "Prompt directories (comma separated)")
 	fs.IntVar(&limit, "limit", ctx.searchOpts.MaxResults, "Maximum number of results")
 	fs.BoolVar(&interactive, "interactive", false, "Launch interactive picker with the query")
+	fs.BoolVar(&explain, "explain", false, "Show how each result was scored and what the cutoff dropped")
+	fs.StringVar(&format, "format", formatText, "Output format: text, json, ndjson or tsv")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if explain {
+		if interactive {
+			return errors.New("--explain cannot be combined with --interactive")
+		}
+		if err := validateFormat(format, formatText, formatJSON, formatNDJSON); err != nil {
+			return fmt.Errorf("--explain: %w", err)
+		}
+	} else if err := validateFormat(format, formatText, formatJSON, formatNDJSON, formatTSV); err != nil {
+		return err
+	}
 
 	queryArgs := fs.Args()
 	if len(queryArgs) == 0 {
@@ -198,36 +347,219 @@ func runSearch(ctx appContext, args []string, in io.Reader, out io.Writer) error
 	if limit > 0 {
 		opts.MaxResults = limit
 	}
+	if explain {
+		results, dropped := search.Explain(prompts, query, opts)
+		if len(results) == 0 && len(dropped) == 0 && format == formatText {
+			return fmt.Errorf("no prompts found for query %q", query)
+		}
+		if format != formatText {
+			records := make([]promptRecord, 0, len(results)+len(dropped))
+			for _, r := range results {
+				records = append(records, newPromptRecord(r.Prompt).withScore(r.Score).withExplanation(r.Explanation, false))
+			}
+			for _, r := range dropped {
+				records = append(records, newPromptRecord(r.Prompt).withExplanation(r.Explanation, true))
+			}
+			return writeRecords(out, format, records)
+		}
+		return writeExplanations(out, results, dropped)
+	}
+
+	tty := format == formatText && isTerminalOutput(out)
+	opts.Spans = tty
 
-	results := search.Search(prompts, query, opts)
-	if len(results) == 0 {
+	results := search.Rank(prompts, query, opts)
+	if len(results) == 0 && format == formatText {
+		// Machine-readable output reports no matches as an empty result.
 		return fmt.Errorf("no prompts found for query %q", query)
 	}
 
 	if interactive {
 		// Use stderr for the interactive UI to keep stdout clean for the prompt output
-		selected, err := ui.SelectPromptWithQuery(prompts, query, opts, in, os.Stderr)
+		selection, err := ui.SelectAndFill(prompts, query, opts, nil, in, os.Stderr)
 		if err != nil {
 			return err
 		}
-		return writePrompt(out, selected.Content)
+		content, err := renderPrompt(selection.Prompt, prompts, selection.Values)
+		if err != nil {
+			return err
+		}
+		return writePrompt(out, content)
 	}
 
-	for _, p := range results {
-		fmt.Fprintf(out, "%s\t%s\n", p.Name, p.Path)
+	if format != formatText {
+		records := make([]promptRecord, 0, len(results))
+		for _, r := range results {
+			records = append(records, newPromptRecord(r.Prompt).withScore(r.Score))
+		}
+		return writeRecords(out, format, records)
+	}
+
+	for _, r := range results {
+		if !tty {
+			fmt.Fprintf(out, "%s\t%s\n", r.Prompt.Name, r.Prompt.Path)
+			continue
+		}
+		name := r.Prompt.Name
+		if m, ok := r.FieldMatch("name"); ok {
+			name = search.Emphasize(name, m.Spans, matchOn, matchOff)
+		}
+		fmt.Fprintf(out, "%s\t%s\n", name, r.Prompt.Path)
+		if m, ok := r.FieldMatch("content"); ok {
+			fmt.Fprintf(out, "    %d: %s\n", m.Line, emphasizeLine(m))
+		}
+	}
+	return nil
+}
+
+// writeExplanations prints each result of pm search --explain with its
+// weighted field scores and total, then the prompts dropped by the cutoff.
+func writeExplanations(out io.Writer, results, dropped []search.Result) error {
+	for _, r := range results {
+		fmt.Fprintf(out, "%s\t%s\n", r.Prompt.Name, r.Prompt.Path)
+		e := r.Explanation
+		if e == nil {
+			fmt.Fprintln(out, "    not scored: the query has only filters")
+			continue
+		}
+		writeComponents(out, *e)
+		if e.SourceWeight != 1 {
+			fmt.Fprintf(out, "    %-8s × %g\n", "source", e.SourceWeight)
+		}
+		fmt.Fprintf(out, "    %-8s %.3f\n", "total", r.Score)
+	}
+
+	if len(dropped) == 0 {
+		return nil
+	}
+	if len(results) > 0 {
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "Dropped by the %g cutoff:\n", dropped[0].Explanation.Cutoff)
+	for _, r := range dropped {
+		e := r.Explanation
+		fmt.Fprintf(out, "%s\t%s\n", r.Prompt.Name, r.Prompt.Path)
+		writeComponents(out, *e)
+		if e.Word != "" {
+			fmt.Fprintf(out, "    %-8s %.3f < %g for the word %q alone\n", "total", e.Sum, e.Cutoff, e.Word)
+			continue
+		}
+		fmt.Fprintf(out, "    %-8s %.3f < %g\n", "total", e.Sum, e.Cutoff)
+	}
+	return nil
+}
+
+func writeComponents(out io.Writer, e search.Explanation) {
+	for _, c := range e.Components {
+		fmt.Fprintf(out, "    %-8s %.3f × %g = %.3f\n", c.Field, c.Score, c.Weight, c.Weighted())
+	}
+}
+
+// Terminal escapes that emphasise matched text in pm search output.
+const (
+	matchOn  = "\x1b[1;33m"
+	matchOff = "\x1b[0m"
+)
+
+// emphasizeLine returns the content line of m without its indentation and
+// with the matched text emphasised.
+func emphasizeLine(m search.Match) string {
+	line := strings.TrimLeft(m.Value, " \t")
+	shift := len(m.Value) - len(line)
+	spans := make([]search.Span, 0, len(m.Spans))
+	for _, s := range m.Spans {
+		if s.Start >= shift {
+			spans = append(spans, search.Span{Start: s.Start - shift, End: s.End - shift})
+		}
+	}
+	return search.Emphasize(line, spans, matchOn, matchOff)
+}
+
+// rankPreset returns the ranking preset named by the global --rank flag.
+func rankPreset(name string) (ranking.Ranking, error) {
+	r, ok := ranking.Preset(name)
+	if !ok {
+		return ranking.Ranking{}, fmt.Errorf("unknown --rank %q (use %s)", name, strings.Join(ranking.Names, ", "))
+	}
+	return r, nil
+}
+
+// rankingKeys are the settings replaced by the global --rank flag.
+var rankingKeys = []string{
+	"fuzzy_search.rank",
+	"fuzzy_search.name_weight",
+	"fuzzy_search.alias_weight",
+	"fuzzy_search.tag_weight",
+	"fuzzy_search.meta_weight",
+	"fuzzy_search.content_weight",
+	"fuzzy_search.cutoff",
+	"fuzzy_search.content_window",
+}
+
+// applyRankFlag makes the preset named by the global --rank flag the ranking
+// for one invocation. It replaces the configured ranking as a whole, weights
+// included, and pm config show reports the flag as the source of those keys.
+func applyRankFlag(ctx *appContext, name string) error {
+	r, err := rankPreset(name)
+	if err != nil {
+		return err
+	}
+	ctx.searchOpts.Ranking = r
+	ctx.settings.FuzzySearch = config.FuzzySearchSettings{
+		MaxResults: ctx.settings.FuzzySearch.MaxResults,
+		Rank:       strings.ToLower(strings.TrimSpace(name)),
+	}
+	if ctx.sources == nil {
+		ctx.sources = make(map[string]config.Source)
+	}
+	for _, key := range rankingKeys {
+		ctx.sources[key] = config.Source{Kind: config.SourceFlag, Name: "--rank"}
 	}
 	return nil
 }
 
+// extractStrictFlag removes the global --strict flag from args, wherever it
+// appears before a "--" terminator, and reports whether it was given.
+func extractStrictFlag(args []string) (bool, []string) {
+	strict := false
+	rest := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if arg == "--strict" || arg == "-strict" {
+			strict = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return strict, rest
+}
+
 func runList(ctx appContext, args []string, out io.Writer) error {
 	fs := flag.NewFlagSet("ls", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 
 	var dirFlag string
+	var format string
+	var showDiagnostics bool
 	fs.StringVar(&dirFlag, "dir", "", "Prompt directories (comma separated)")
+	fs.StringVar(&format, "format", formatText, "Output format: text, json, ndjson or tsv")
+	fs.BoolVar(&showDiagnostics, "diagnostics", false, "List the problems found while loading instead of the prompts")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if err := validateFormat(format, formatText, formatJSON, formatNDJSON, formatTSV); err != nil {
+		return err
+	}
+	if showDiagnostics {
+		_, diagnostics, err := prompt.LoadWithDiagnostics(promptSources(ctx, dirFlag), ctx.promptOpts)
+		if err != nil {
+			return err
+		}
+		return writeDiagnostics(out, format, diagnostics)
+	}
 
 	prompts, err := loadPrompts(ctx, dirFlag)
 	if err != nil {
@@ -235,7 +567,22 @@ func runList(ctx appContext, args []string, out io.Writer) error {
 	}
 
 	results := search.Search(prompts, "", search.Options{})
+	if format != formatText {
+		records := make([]promptRecord, 0, len(results))
+		for _, p := range results {
+			records = append(records, newPromptRecord(p))
+		}
+		return writeRecords(out, format, records)
+	}
+
+	// Prompts sharing a name are listed by ID so that each line can be passed
+	// back to cat, mesh or edit.
+	collisions := prompt.Collisions(results)
 	for _, p := range results {
+		if _, ok := collisions[strings.ToLower(p.Name)]; ok {
+			fmt.Fprintf(out, "%s (name %q is shared)\n", p.ID, p.Name)
+			continue
+		}
 		fmt.Fprintln(out, p.Name)
 	}
 	return nil
@@ -246,10 +593,17 @@ func runCat(ctx appContext, args []string, out io.Writer) error {
 	fs.SetOutput(io.Discard)
 
 	var dirFlag string
+	var format string
+	vars := varFlags{}
 	fs.StringVar(&dirFlag, "dir", "", "Prompt directories (comma separated)")
+	fs.Var(vars, "var", "Template variable as key=value (repeatable)")
+	fs.StringVar(&format, "format", formatText, "Output format: text, json, ndjson or tsv")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if err := validateFormat(format, formatText, formatJSON, formatNDJSON, formatTSV); err != nil {
+		return err
+	}
 
 	names := fs.Args()
 	if len(names) == 0 {
@@ -262,12 +616,20 @@ func runCat(ctx appContext, args []string, out io.Writer) error {
 		return err
 	}
 
-	promptItem, ok := findPromptByName(prompts, name)
-	if !ok {
-		return fmt.Errorf("prompt %q not found", name)
+	promptItem, err := prompt.Resolve(prompts, name)
+	if err != nil {
+		return err
+	}
+
+	content, err := renderPrompt(promptItem, prompts, vars)
+	if err != nil {
+		return err
 	}
 
-	return writePrompt(out, promptItem.Content)
+	if format != formatText {
+		return writeRecord(out, format, newPromptRecord(promptItem).withContent(content))
+	}
+	return writePrompt(out, content)
 }
 
 func runMesh(ctx appContext, args []string, in io.Reader, out io.Writer) error {
@@ -275,13 +637,17 @@ func runMesh(ctx appContext, args []string, in io.Reader, out io.Writer) error {
 	fs.SetOutput(io.Discard)
 
 	var dirFlag string
+	var interactive bool
+	vars := varFlags{}
 	fs.StringVar(&dirFlag, "dir", "", "Prompt directories (comma separated)")
+	fs.BoolVar(&interactive, "interactive", false, "Pick the prompts to combine in the picker")
+	fs.Var(vars, "var", "Template variable as key=value (repeatable)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
 	names := fs.Args()
-	if len(names) == 0 {
+	if len(names) == 0 && !interactive {
 		return errors.New("mesh requires at least one prompt name")
 	}
 
@@ -290,12 +656,30 @@ func runMesh(ctx appContext, args []string, in io.Reader, out io.Writer) error {
 		return err
 	}
 
-	for _, name := range names {
-		promptItem, ok := findPromptByName(prompts, name)
-		if !ok {
-			return fmt.Errorf("prompt %q not found", name)
+	var selected []prompt.Prompt
+	if interactive {
+		// With --interactive the arguments seed the picker's filter.
+		sorted := search.Search(prompts, "", search.Options{})
+		selected, err = ui.SelectPrompts(sorted, strings.Join(names, " "), ctx.searchOpts, in, os.Stderr)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, name := range names {
+			promptItem, err := prompt.Resolve(prompts, name)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, promptItem)
+		}
+	}
+
+	for _, promptItem := range selected {
+		content, err := renderPrompt(promptItem, prompts, vars)
+		if err != nil {
+			return err
 		}
-		if err := writePrompt(out, promptItem.Content); err != nil {
+		if err := writePrompt(out, content); err != nil {
 			return err
 		}
 		fmt.Fprintln(out)
@@ -312,21 +696,265 @@ func runMesh(ctx appContext, args []string, in io.Reader, out io.Writer) error {
 	return nil
 }
 
-func loadPrompts(ctx appContext, dirFlag string) ([]prompt.Prompt, error) {
-	dirs := ctx.settings.DefaultDirs
+func runNew(ctx appContext, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var dirFlag, tagsFlag, aliasesFlag, summary string
+	fs.StringVar(&dirFlag, "dir", "", "Directory to create the prompt in")
+	fs.StringVar(&tagsFlag, "tags", "", "Tags (comma separated)")
+	fs.StringVar(&aliasesFlag, "aliases", "", "Aliases (comma separated)")
+	fs.StringVar(&summary, "summary", "", "One line summary")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if name == "" {
+		return errors.New("new requires a prompt name")
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid prompt name %q: must not contain path separators", name)
+	}
+
+	target, err := newPromptTarget(ctx, dirFlag)
+	if err != nil {
+		return err
+	}
+	dir := target.Path
+
+	if existing, ok, err := existingPrompt(ctx, target, name); err != nil {
+		return err
+	} else if ok {
+		return fmt.Errorf("prompt %q already exists at %s", name, existing)
+	}
+
+	data, err := prompt.Encode(prompt.Header{
+		Summary: strings.TrimSpace(summary),
+		Tags:    splitAndTrim(tagsFlag),
+		Aliases: splitAndTrim(aliasesFlag),
+	}, "# "+name+"\n\n")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	extensions := target.Extensions
+	if len(extensions) == 0 {
+		extensions = ctx.promptOpts.Extensions
+	}
+	path := filepath.Join(dir, name+newPromptExtension(extensions))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("prompt file %s already exists", path)
+		}
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, path)
+
+	if err := editor.Open(path); err != nil && !errors.Is(err, editor.ErrNoEditor) {
+		return fmt.Errorf("open editor: %w", err)
+	}
+	return nil
+}
+
+func runEdit(ctx appContext, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var dirFlag string
+	fs.StringVar(&dirFlag, "dir", "", "Prompt directories (comma separated)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		return errors.New("edit requires a prompt name")
+	}
+	name := strings.Join(names, " ")
+
+	prompts, err := loadPrompts(ctx, dirFlag)
+	if err != nil {
+		return err
+	}
+
+	promptItem, err := prompt.Resolve(prompts, name)
+	if err != nil {
+		return err
+	}
+	if promptItem.ReadOnly {
+		return fmt.Errorf("prompt %q is in read-only source %q", promptItem.ID, promptItem.Source)
+	}
+
+	return editor.Open(promptItem.Path)
+}
+
+// newPromptTarget picks the source pm new writes to: the one named or given
+// by --dir, or the first writable configured source.
+func newPromptTarget(ctx appContext, dirFlag string) (prompt.Source, error) {
+	sources := promptSources(ctx, dirFlag)
 	if dirFlag != "" {
-		dirs = splitAndTrim(dirFlag)
+		if len(sources) != 1 {
+			return prompt.Source{}, errors.New("new takes a single --dir")
+		}
+		if sources[0].ReadOnly {
+			return prompt.Source{}, fmt.Errorf("source %q is read-only", sources[0].Name)
+		}
+		return sources[0], nil
+	}
+
+	for _, source := range sources {
+		if !source.ReadOnly {
+			return source, nil
+		}
+	}
+	return prompt.Source{}, errors.New("no writable prompt directory configured: use --dir")
+}
+
+// existingPrompt returns the path of a prompt in the target source that a
+// new prompt called name would collide with: a prompt of that name, or a file
+// in the target directory with that base name whatever its extension.
+func existingPrompt(ctx appContext, target prompt.Source, name string) (string, bool, error) {
+	entries, err := os.ReadDir(target.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", false, err
+	}
+	for _, entry := range entries {
+		base := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if !entry.IsDir() && strings.EqualFold(base, name) {
+			return filepath.Join(target.Path, entry.Name()), true, nil
+		}
+	}
+
+	prompts, err := prompt.LoadFromSources([]prompt.Source{target}, ctx.promptOpts)
+	if err != nil {
+		return "", false, err
+	}
+	if p, ok := prompt.FindByName(prompts, name); ok {
+		return p.Path, true, nil
+	}
+	return "", false, nil
+}
+
+// newPromptExtension picks the extension for new prompt files, preferring
+// Markdown when it is one of the configured extensions.
+func newPromptExtension(extensions []string) string {
+	for _, ext := range extensions {
+		if strings.EqualFold(ext, ".md") {
+			return ".md"
+		}
+	}
+	if len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ".md"
+}
+
+// loadPrompts loads the prompts of the sources chosen by dirFlag. Files that
+// could not be loaded are counted on stderr and left out, unless --strict
+// makes them an error.
+func loadPrompts(ctx appContext, dirFlag string) ([]prompt.Prompt, error) {
+	prompts, diagnostics, err := prompt.LoadWithDiagnostics(promptSources(ctx, dirFlag), ctx.promptOpts)
+	if err != nil {
+		return nil, err
+	}
+	problems := 0
+	for _, d := range diagnostics {
+		if d.Fatal() {
+			problems++
+		}
+	}
+	if problems > 0 {
+		fmt.Fprintf(os.Stderr, "pm: %d file(s) had problems; run pm ls --diagnostics for details\n", problems)
 	}
-	return prompt.LoadFromDirs(dirs, ctx.promptOpts)
+	return prompts, nil
 }
 
-func findPromptByName(prompts []prompt.Prompt, name string) (prompt.Prompt, bool) {
-	for _, p := range prompts {
-		if strings.EqualFold(p.Name, name) {
-			return p, true
+// promptSources returns the sources to load: the [[sources]] tables when
+// there are any, the default directories otherwise. --dir entries name a
+// configured source or give a directory to scan with the global settings.
+func promptSources(ctx appContext, dirFlag string) []prompt.Source {
+	if dirFlag != "" {
+		var sources []prompt.Source
+		for _, entry := range splitAndTrim(dirFlag) {
+			if source, ok := ctx.settings.Source(entry); ok {
+				sources = append(sources, newPromptSource(source))
+				continue
+			}
+			sources = append(sources, prompt.Source{Path: entry})
+		}
+		return sources
+	}
+
+	if len(ctx.settings.Sources) > 0 {
+		sources := make([]prompt.Source, 0, len(ctx.settings.Sources))
+		for _, source := range ctx.settings.Sources {
+			sources = append(sources, newPromptSource(source))
 		}
+		return sources
 	}
-	return prompt.Prompt{}, false
+
+	sources := make([]prompt.Source, 0, len(ctx.settings.DefaultDirs))
+	for _, dir := range ctx.settings.DefaultDirs {
+		sources = append(sources, prompt.Source{Path: dir})
+	}
+	return sources
+}
+
+func newPromptSource(s config.SourceSettings) prompt.Source {
+	return prompt.Source{
+		Name:           s.Name,
+		Path:           s.Path,
+		Extensions:     s.Extensions,
+		IgnorePatterns: s.IgnorePatterns,
+		MaxFileSize:    int64(s.MaxFileSizeKB) * 1024,
+		ReadOnly:       s.ReadOnly,
+		Weight:         s.Weight,
+	}
+}
+
+// renderPrompt expands the prompt's includes from the loaded set and fills its
+// template placeholders with the --var values.
+func renderPrompt(p prompt.Prompt, library []prompt.Prompt, vars map[string]string) (string, error) {
+	content, err := prompt.Render(p, prompt.RenderOptions{Values: vars, Library: library})
+	var missing *prompt.MissingValuesError
+	if errors.As(err, &missing) {
+		return "", fmt.Errorf("%w (set them with --var key=value)", err)
+	}
+	return content, err
+}
+
+// varFlags collects repeatable --var key=value flags.
+type varFlags map[string]string
+
+func (v varFlags) String() string {
+	pairs := make([]string, 0, len(v))
+	for key, value := range v {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (v varFlags) Set(raw string) error {
+	key, value, ok := strings.Cut(raw, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("invalid --var %q: expected key=value", raw)
+	}
+	v[key] = value
+	return nil
 }
 
 func splitAndTrim(input string) []string {
@@ -346,15 +974,29 @@ func printUsage(out io.Writer) {
 
 Usage:
   pm [--query <query>] [--dir <dir>]
-  pm pick [--query <query>] [--interactive]
-  pm search [--limit N] <query>
-  pm ls
-  pm cat <name>
-  pm mesh <name> [<name>...]
+  pm pick [--query <query>] [--interactive] [--var key=value]
+  pm search [--limit N] [--format F] [--explain] <query>
+  pm ls [--format F] [--diagnostics]
+  pm cat [--var key=value] [--format F] <name>
+  pm mesh [--var key=value] <name> [<name>...]
+  pm mesh --interactive [--var key=value] [<query>]
+  pm edit <name>
+  pm new [--dir <dir>] [--tags a,b] [--aliases a,b] [--summary text] <name>
+  pm completion bash|zsh|fish
+  pm config show [--format text|json]
+  pm config get <key>
+  pm config set [--file <path>] <key> <value>
+  pm config path
+  pm config validate [<file>...]
 
 Flags:
+  --config        Settings file to use instead of the standard locations
+  --strict        Fail on the first unreadable file or bad front matter
+  --rank          Ranking preset for this search: balanced, name-first or content-first
   --dir           Override prompt directories (comma separated)
-  --query         Provide a query for prompt selection`)
+  --query         Provide a query for prompt selection
+  --var           Fill a {{key}} placeholder in the prompt (repeatable)
+  --format        Output format for ls, search and cat: text, json, ndjson or tsv`)
 }
 
 func outputPrompt(content string, copyToClipboard bool, out io.Writer) error {
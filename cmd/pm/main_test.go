@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -51,6 +54,107 @@ func TestRunMeshReadsPipedInput(t *testing.T) {
 	}
 }
 
+// terminalOutput is a buffer that reports itself as a terminal.
+type terminalOutput struct {
+	bytes.Buffer
+}
+
+func (t *terminalOutput) IsTerminal() bool { return true }
+
+func TestRunSearchHighlightsMatchesOnTerminal(t *testing.T) {
+	ctx := testAppContext()
+
+	var plain bytes.Buffer
+	if err := runSearch(ctx, []string{"review"}, nil, &plain); err != nil {
+		t.Fatalf("runSearch error = %v", err)
+	}
+	if strings.Contains(plain.String(), "\x1b[") || strings.Contains(plain.String(), "    1: ") {
+		t.Fatalf("expected piped output to stay plain, got %q", plain.String())
+	}
+
+	var tty terminalOutput
+	if err := runSearch(ctx, []string{"review"}, nil, &tty); err != nil {
+		t.Fatalf("runSearch error = %v", err)
+	}
+	got := tty.String()
+	if !strings.Contains(got, "code-"+matchOn+"review"+matchOff) {
+		t.Fatalf("expected the matched name to be emphasised, got %q", got)
+	}
+	if !strings.Contains(got, "    1: # Code "+matchOn+"Review"+matchOff+" Checklist\n") {
+		t.Fatalf("expected the matching content line, got %q", got)
+	}
+}
+
+func TestRunSearchExplain(t *testing.T) {
+	ctx := testAppContext()
+
+	var out bytes.Buffer
+	if err := runSearch(ctx, []string{"--explain", "review"}, nil, &out); err != nil {
+		t.Fatalf("runSearch error = %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"code-review\t",
+		"    name     1.000 × 5 = 5.000\n",
+		"    content  1.500 × 1 = 1.500\n",
+		"    total    6.500\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in output, got %q", want, got)
+		}
+	}
+
+	out.Reset()
+	if err := runSearch(ctx, []string{"--explain", "code brief"}, nil, &out); err != nil {
+		t.Fatalf("runSearch error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Dropped by the 0.25 cutoff:\nproduct-brief\t") ||
+		!strings.Contains(out.String(), `for the word "code" alone`) {
+		t.Fatalf("expected product-brief to be reported as dropped, got %q", out.String())
+	}
+
+	if err := runSearch(ctx, []string{"--explain", "--format", "tsv", "review"}, nil, &out); err == nil {
+		t.Fatal("expected --explain to reject tsv")
+	}
+}
+
+func TestRunMeshInteractiveUsesSelectionOrder(t *testing.T) {
+	ctx := testAppContext()
+	var out bytes.Buffer
+	// Without a terminal the picker reads the selection from the input.
+	input := strings.NewReader("product-brief, code-review\n")
+
+	if err := runMesh(ctx, []string{"--interactive"}, input, &out); err != nil {
+		t.Fatalf("runMesh error = %v", err)
+	}
+
+	brief := strings.Index(out.String(), "# Product Brief")
+	review := strings.Index(out.String(), "# Code Review")
+	if brief < 0 || review < 0 || brief > review {
+		t.Fatalf("expected product-brief before code-review, got %q", out.String())
+	}
+}
+
+func TestRunMeshInteractiveUsesRanking(t *testing.T) {
+	// "checklist" only appears in the content of code-review. The balanced
+	// ranking lists it first; name-first ignores content, so nothing matches
+	// and the picker lists every prompt, brainstorm first.
+	for preset, want := range map[string]string{"balanced": "# Code Review", "name-first": "Brainstorm"} {
+		ctx := testAppContext()
+		var err error
+		if ctx.searchOpts.Ranking, err = rankPreset(preset); err != nil {
+			t.Fatalf("rankPreset error = %v", err)
+		}
+		var out bytes.Buffer
+		if err := runMesh(ctx, []string{"--interactive", "checklist"}, strings.NewReader("1\n"), &out); err != nil {
+			t.Fatalf("%s: runMesh error = %v", preset, err)
+		}
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("%s: expected %q in the output, got %q", preset, want, out.String())
+		}
+	}
+}
+
 func TestRunPickWithQueryCopiesSelection(t *testing.T) {
 	ctx := testAppContext()
 	var copied string
@@ -61,7 +165,7 @@ func TestRunPickWithQueryCopiesSelection(t *testing.T) {
 	defer clipboard.SetProvider(nil)
 
 	var out bytes.Buffer
-	if err := runPickWithQuery(ctx, "code-review", "", true, &out); err != nil {
+	if err := runPickWithQuery(ctx, "code-review", "", true, nil, &out); err != nil {
 		t.Fatalf("runPickWithQuery error = %v", err)
 	}
 
@@ -82,7 +186,7 @@ func TestRunPickInteractiveCopiesSelection(t *testing.T) {
 	input := strings.NewReader("1\n")
 	var out bytes.Buffer
 
-	if err := runPickInteractive(ctx, "", true, input, &out); err != nil {
+	if err := runPickInteractive(ctx, "", true, nil, input, &out); err != nil {
 		t.Fatalf("runPickInteractive error = %v", err)
 	}
 
@@ -91,6 +195,392 @@ func TestRunPickInteractiveCopiesSelection(t *testing.T) {
 	}
 }
 
+func TestRunCatFillsTemplateVariables(t *testing.T) {
+	ctx := testAppContext()
+	ctx.settings.DefaultDirs = []string{writeTemplatePrompt(t)}
+
+	var out bytes.Buffer
+	if err := runCat(ctx, []string{"--var", "language=Go", "--var", "focus=errors", "review"}, &out); err != nil {
+		t.Fatalf("runCat error = %v", err)
+	}
+
+	if got := out.String(); got != "Review this Go code, focusing on errors.\n" {
+		t.Fatalf("unexpected rendered output %q", got)
+	}
+}
+
+func TestRunCatListsUnfilledPlaceholders(t *testing.T) {
+	ctx := testAppContext()
+	ctx.settings.DefaultDirs = []string{writeTemplatePrompt(t)}
+
+	var out bytes.Buffer
+	err := runCat(ctx, []string{"--var", "language=Go", "review"}, &out)
+	if err == nil {
+		t.Fatal("expected error for unfilled placeholders")
+	}
+	if !strings.Contains(err.Error(), "focus") || strings.Contains(err.Error(), "language") {
+		t.Fatalf("expected error to list only the unfilled placeholder, got %v", err)
+	}
+}
+
+func TestRunPickInteractiveAsksForVariables(t *testing.T) {
+	ctx := testAppContext()
+	ctx.settings.DefaultDirs = []string{writeTemplatePrompt(t)}
+
+	input := strings.NewReader("1\nRust\n")
+	var out bytes.Buffer
+
+	if err := runPickInteractive(ctx, "", false, map[string]string{"focus": "lifetimes"}, input, &out); err != nil {
+		t.Fatalf("runPickInteractive error = %v", err)
+	}
+
+	if got := out.String(); got != "Review this Rust code, focusing on lifetimes.\n" {
+		t.Fatalf("unexpected rendered output %q", got)
+	}
+}
+
+func TestRunNewScaffoldsPrompt(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	ctx := testAppContext()
+	dir := filepath.Join(t.TempDir(), "prompts")
+	ctx.settings.DefaultDirs = []string{dir}
+
+	var out bytes.Buffer
+	args := []string{"--tags", "review, go", "--aliases", "pr review", "--summary", "Review a PR", "pr-review"}
+	if err := runNew(ctx, args, &out); err != nil {
+		t.Fatalf("runNew error = %v", err)
+	}
+
+	path := filepath.Join(dir, "pr-review.md")
+	if strings.TrimSpace(out.String()) != path {
+		t.Fatalf("expected created path to be printed, got %q", out.String())
+	}
+
+	prompts, err := loadPrompts(ctx, "")
+	if err != nil {
+		t.Fatalf("loadPrompts error = %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].Name != "pr-review" {
+		t.Fatalf("expected new prompt to load, got %v", prompts)
+	}
+	if strings.Join(prompts[0].Tags, ",") != "review,go" || prompts[0].FrontMatter["summary"] != "Review a PR" {
+		t.Fatalf("unexpected front matter %#v", prompts[0].FrontMatter)
+	}
+
+	if err := runNew(ctx, []string{"PR-Review"}, &out); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected existing prompt to be refused, got %v", err)
+	}
+}
+
+func TestRunNewRefusesOtherExtensionsInTargetDir(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	ctx := testAppContext()
+	other := t.TempDir()
+	notes := filepath.Join(other, "notes.json")
+	for path, content := range map[string]string{filepath.Join(other, "foo.txt"): "Existing text prompt", notes: "{}"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runNew(ctx, []string{"--dir", other, "foo"}, &out); err == nil || !strings.Contains(err.Error(), "foo.txt") {
+		t.Fatalf("expected foo.txt to block foo.md, got %v", err)
+	}
+	if err := runNew(ctx, []string{"--dir", other, "notes"}, &out); err == nil || !strings.Contains(err.Error(), notes) {
+		t.Fatalf("expected a file of any extension to block the name, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(other, "foo.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected foo.md not to be created, got %v", err)
+	}
+}
+
+func TestRunNewIgnoresPromptsInOtherSources(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	ctx := testAppContext()
+	personal := t.TempDir()
+	if err := os.WriteFile(filepath.Join(personal, "style.md"), []byte("Personal style"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	ctx.settings.DefaultDirs = []string{personal}
+	other := filepath.Join(t.TempDir(), "other")
+
+	var out bytes.Buffer
+	if err := runNew(ctx, []string{"--dir", other, "style"}, &out); err != nil {
+		t.Fatalf("expected style to be created next to an unrelated source, got %v", err)
+	}
+	if path := filepath.Join(other, "style.md"); strings.TrimSpace(out.String()) != path {
+		t.Fatalf("expected %s, got %q", path, out.String())
+	}
+}
+
+func TestRunEditOpensPromptInEditor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor stub is a shell script")
+	}
+
+	ctx := testAppContext()
+	ctx.settings.DefaultDirs = []string{writeTemplatePrompt(t)}
+
+	tmp := t.TempDir()
+	marker := filepath.Join(tmp, "opened")
+	script := filepath.Join(tmp, "editor.sh")
+	stub := "#!/bin/sh\necho \"$1\" > " + marker + "\n"
+	if err := os.WriteFile(script, []byte(stub), 0o700); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Setenv("VISUAL", script)
+
+	if err := runEdit(ctx, []string{"Review"}); err != nil {
+		t.Fatalf("runEdit error = %v", err)
+	}
+
+	opened, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("expected editor to run: %v", err)
+	}
+	if filepath.Base(strings.TrimSpace(string(opened))) != "review.md" {
+		t.Fatalf("expected editor to receive the prompt path, got %q", opened)
+	}
+}
+
+func TestExtractConfigFlag(t *testing.T) {
+	path, rest, err := extractConfigFlag([]string{"ls", "--config", "a.toml", "--format=json", "--", "--config=b.toml"})
+	if err != nil {
+		t.Fatalf("extractConfigFlag error = %v", err)
+	}
+	if path != "a.toml" {
+		t.Fatalf("expected config path a.toml, got %q", path)
+	}
+	want := []string{"ls", "--format=json", "--", "--config=b.toml"}
+	if strings.Join(rest, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected remaining args %v, got %v", want, rest)
+	}
+
+	if _, _, err := extractConfigFlag([]string{"--config"}); err == nil {
+		t.Fatal("expected error for --config without a value")
+	}
+}
+
+func TestExtractStrictFlag(t *testing.T) {
+	strict, rest := extractStrictFlag([]string{"ls", "--strict", "--", "--strict"})
+	if !strict {
+		t.Fatal("expected --strict to be found")
+	}
+	if strings.Join(rest, " ") != "ls -- --strict" {
+		t.Fatalf("unexpected remaining args %v", rest)
+	}
+}
+
+func TestRankFlagReplacesRanking(t *testing.T) {
+	rank, rest, err := extractValueFlag([]string{"search", "--rank=name-first", "brief"}, "rank")
+	if err != nil || rank != "name-first" || strings.Join(rest, " ") != "search brief" {
+		t.Fatalf("unexpected --rank extraction %q %v %v", rank, rest, err)
+	}
+
+	ctx := testAppContext()
+	one := 1.0
+	ctx.settings.FuzzySearch.NameWeight = &one
+	if err := applyRankFlag(&ctx, rank); err != nil {
+		t.Fatalf("applyRankFlag error = %v", err)
+	}
+	var out bytes.Buffer
+	if err := runSearch(ctx, []string{"--explain", "brief"}, nil, &out); err != nil {
+		t.Fatalf("runSearch error = %v", err)
+	}
+	if !strings.Contains(out.String(), "    name     1.000 × 10 = 10.000\n") {
+		t.Fatalf("expected the name-first weights, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runConfig(ctx, []string{"show"}, &out); err != nil {
+		t.Fatalf("runConfig error = %v", err)
+	}
+	for _, want := range []string{
+		"fuzzy_search.rank = 'name-first'  # flag --rank\n",
+		"fuzzy_search.name_weight = 10.0  # flag --rank\n",
+		"fuzzy_search.max_results = 20  # default\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := rankPreset("fastest"); err == nil || !strings.Contains(err.Error(), "balanced, name-first, content-first") {
+		t.Fatalf("expected an error listing the presets, got %v", err)
+	}
+}
+
+func TestRunListDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"good.md": "Fine.",
+		"bad.md":  "---\ntitle: ok\nsummary: a: b\n---\nBody.\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile error = %v", err)
+		}
+	}
+	ctx := testAppContext()
+	ctx.settings.DefaultDirs = []string{dir}
+
+	var out bytes.Buffer
+	if err := runList(ctx, nil, &out); err != nil {
+		t.Fatalf("runList error = %v", err)
+	}
+	if out.String() != "bad\ngood\n" {
+		t.Fatalf("expected both prompts to be listed, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runList(ctx, []string{"--diagnostics"}, &out); err != nil {
+		t.Fatalf("runList --diagnostics error = %v", err)
+	}
+	want := filepath.Join(dir, "bad.md") + ":3: front-matter: mapping values are not allowed in this context\n"
+	if out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+
+	ctx.promptOpts.Strict = true
+	if err := runList(ctx, nil, &out); err == nil || !strings.Contains(err.Error(), "bad.md:3") {
+		t.Fatalf("expected --strict to fail on bad.md, got %v", err)
+	}
+}
+
+func TestNewAppContextRejectsMissingExplicitConfig(t *testing.T) {
+	if _, err := newAppContext(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Fatal("expected error for a missing --config file")
+	}
+}
+
+func TestNameCollisionsUseQualifiedIDs(t *testing.T) {
+	base := t.TempDir()
+	var dirs []string
+	for _, name := range []string{"work", "personal"} {
+		dir := filepath.Join(base, name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "review.md"), []byte(name+" review\n"), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		dirs = append(dirs, dir)
+	}
+	ctx := testAppContext()
+	ctx.settings.DefaultDirs = dirs
+
+	var out bytes.Buffer
+	if err := runList(ctx, nil, &out); err != nil {
+		t.Fatalf("runList error = %v", err)
+	}
+	if !strings.Contains(out.String(), "work:review (name \"review\" is shared)") {
+		t.Fatalf("expected the collision to be flagged, got %q", out.String())
+	}
+
+	err := runCat(ctx, []string{"review"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "work:review, personal:review") {
+		t.Fatalf("expected an ambiguity error listing candidates, got %v", err)
+	}
+
+	out.Reset()
+	if err := runCat(ctx, []string{"personal:review"}, &out); err != nil {
+		t.Fatalf("runCat error = %v", err)
+	}
+	if out.String() != "personal review\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+func TestPickResolvesRefsBeforeSearching(t *testing.T) {
+	base := t.TempDir()
+	var dirs []string
+	for _, name := range []string{"work", "personal"} {
+		dir := filepath.Join(base, name)
+		if err := os.MkdirAll(filepath.Join(dir, "code"), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "review.md"), []byte(name+" review\n"), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		dirs = append(dirs, dir)
+	}
+	if err := os.WriteFile(filepath.Join(dirs[0], "code", "lint.md"), []byte("work lint\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	ctx := testAppContext()
+	ctx.settings.DefaultDirs = dirs
+
+	var out bytes.Buffer
+	if err := runPickWithQuery(ctx, "code/lint", "", false, nil, &out); err != nil {
+		t.Fatalf("expected a relative path to pick its prompt, got %v", err)
+	}
+	if out.String() != "work lint\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	out.Reset()
+	err := runPickWithQuery(ctx, "review", "", false, nil, &out)
+	var ambiguous *prompt.AmbiguousError
+	if !errors.As(err, &ambiguous) || strings.Join(ambiguous.Candidates, ", ") != "work:review, personal:review" {
+		t.Fatalf("expected an ambiguity error listing candidates, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected nothing to be picked, got %q", out.String())
+	}
+
+	if err := runPickWithQuery(ctx, "lnt", "", false, nil, &out); err != nil || out.String() != "work lint\n" {
+		t.Fatalf("expected other queries to be searched for, got %q, %v", out.String(), err)
+	}
+}
+
+func TestSourcesHonourNamesAndReadOnly(t *testing.T) {
+	team := writeTemplatePrompt(t)
+	vault := t.TempDir()
+	ctx := testAppContext()
+	ctx.settings.Sources = []config.SourceSettings{
+		{Name: "team", Path: team, ReadOnly: true},
+		{Name: "vault", Path: vault},
+	}
+
+	var out bytes.Buffer
+	if err := runList(ctx, []string{"--dir", "team"}, &out); err != nil {
+		t.Fatalf("runList error = %v", err)
+	}
+	if out.String() != "review\n" {
+		t.Fatalf("expected --dir to select the team source, got %q", out.String())
+	}
+
+	if err := runEdit(ctx, []string{"team:review"}); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("expected edit to refuse a read-only source, got %v", err)
+	}
+	if err := runNew(ctx, []string{"--dir", "team", "notes"}, &out); err == nil {
+		t.Fatal("expected new to refuse a read-only source")
+	}
+
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	out.Reset()
+	if err := runNew(ctx, []string{"notes"}, &out); err != nil {
+		t.Fatalf("runNew error = %v", err)
+	}
+	if filepath.Dir(strings.TrimSpace(out.String())) != vault {
+		t.Fatalf("expected the first writable source to be used, got %q", out.String())
+	}
+}
+
+func writeTemplatePrompt(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	content := []byte("Review this {{language}} code, focusing on {{focus}}.\n")
+	if err := os.WriteFile(filepath.Join(dir, "review.md"), content, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return dir
+}
+
 func testAppContext() appContext {
 	dir := filepath.Join("..", "..", "testdata", "prompts")
 	settings := config.Settings{
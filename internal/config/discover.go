@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectFileName is the project-local settings file merged on top of the
+// user settings. It is found by walking up from the working directory.
+const ProjectFileName = ".pm.toml"
+
+// Locate returns the settings file to use. An explicit path (from --config)
+// wins, followed by $PM_CONFIG, $XDG_CONFIG_HOME/pm/settings.toml and
+// ~/.config/pm/settings.toml. Explicit paths are returned as given; the
+// standard locations are only used when the file exists. An empty result
+// means no settings file was found.
+func Locate(explicit string) string {
+	if explicit != "" {
+		return expandHome(explicit)
+	}
+	if env := os.Getenv("PM_CONFIG"); env != "" {
+		return expandHome(env)
+	}
+
+	for _, candidate := range userCandidates() {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// DefaultPath returns where a new user settings file is created:
+// $XDG_CONFIG_HOME/pm/settings.toml, or ~/.config/pm/settings.toml.
+func DefaultPath() string {
+	if candidates := userCandidates(); len(candidates) > 0 {
+		return candidates[0]
+	}
+	return ""
+}
+
+// DefaultCacheDir returns the per-user directory for the prompt index, under
+// os.UserCacheDir, or "" to disable the index when there is none.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pm")
+}
+
+func userCandidates() []string {
+	var candidates []string
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		candidates = append(candidates, filepath.Join(xdg, "pm", "settings.toml"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".config", "pm", "settings.toml"))
+	}
+	return candidates
+}
+
+// FindProjectFile walks up from start looking for a project settings file and
+// returns its path, or an empty string when there is none.
+func FindProjectFile(start string) string {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		candidate := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocateOrder(t *testing.T) {
+	home := t.TempDir()
+	xdg := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("PM_CONFIG", "")
+
+	if got := Locate(""); got != "" {
+		t.Fatalf("expected no config file, got %q", got)
+	}
+
+	homeConfig := filepath.Join(home, ".config", "pm", "settings.toml")
+	writeSettings(t, homeConfig, "")
+	if got := Locate(""); got != homeConfig {
+		t.Fatalf("expected %q, got %q", homeConfig, got)
+	}
+
+	xdgConfig := filepath.Join(xdg, "pm", "settings.toml")
+	writeSettings(t, xdgConfig, "")
+	if got := Locate(""); got != xdgConfig {
+		t.Fatalf("expected %q, got %q", xdgConfig, got)
+	}
+
+	t.Setenv("PM_CONFIG", "/env/settings.toml")
+	if got := Locate(""); got != "/env/settings.toml" {
+		t.Fatalf("expected $PM_CONFIG to win, got %q", got)
+	}
+
+	if got := Locate("~/explicit.toml"); got != filepath.Join(home, "explicit.toml") {
+		t.Fatalf("expected explicit path to win, got %q", got)
+	}
+}
+
+func TestFindProjectFileWalksUp(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, ProjectFileName)
+	writeSettings(t, project, "")
+
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	if got := FindProjectFile(nested); got != project {
+		t.Fatalf("expected %q, got %q", project, got)
+	}
+}
+
+func TestLoadFilesMergesProjectFile(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "settings.toml")
+	writeSettings(t, user, `
+default_dir = "user-prompts"
+
+[fuzzy_search]
+max_results = 5
+
+[ui]
+truncate_length = 80
+`)
+	projectDir := filepath.Join(dir, "project")
+	project := filepath.Join(projectDir, ProjectFileName)
+	writeSettings(t, project, `
+default_dir = ["prompts", "/abs/prompts"]
+
+[fuzzy_search]
+max_results = 7
+`)
+
+	settings := LoadFiles(user, project)
+
+	want := []string{filepath.Join(projectDir, "prompts"), "/abs/prompts"}
+	if len(settings.DefaultDirs) != 2 || settings.DefaultDirs[0] != want[0] || settings.DefaultDirs[1] != want[1] {
+		t.Fatalf("expected project dirs %v, got %v", want, settings.DefaultDirs)
+	}
+	if settings.FuzzySearch.MaxResults != 7 {
+		t.Fatalf("expected project max_results 7, got %d", settings.FuzzySearch.MaxResults)
+	}
+	if settings.UI.TruncateLength != 80 {
+		t.Fatalf("expected user truncate_length 80 to be kept, got %d", settings.UI.TruncateLength)
+	}
+}
+
+func writeSettings(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestDefaultCacheDirIsPerUser(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", base)
+	t.Setenv("HOME", base)
+	t.Setenv("LocalAppData", base)
+
+	dir := Defaults().CacheDir
+	if !strings.HasPrefix(dir, base) || filepath.Base(dir) != "pm" {
+		t.Fatalf("expected the index under the user cache directory %s, got %q", base, dir)
+	}
+}
+
+func TestEmptyCacheDirDisablesIndex(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "settings.toml")
+	writeSettings(t, user, "cache_dir = \"/tmp/pm-index\"\n")
+	project := filepath.Join(dir, "project", ProjectFileName)
+	writeSettings(t, project, "cache_dir = \"\"\n")
+
+	settings, sources, _ := LoadWithSources(user, project)
+	if settings.CacheDir != "" {
+		t.Fatalf("expected an empty cache_dir to disable the index, got %q", settings.CacheDir)
+	}
+	if sources["cache_dir"].Name != project {
+		t.Fatalf("expected cache_dir to come from %s, got %+v", project, sources["cache_dir"])
+	}
+
+	data, err := Set(nil, "cache_dir", "")
+	if err != nil || string(data) != "cache_dir = ''\n" {
+		t.Fatalf("Set(cache_dir, \"\") = %q, %v", data, err)
+	}
+}
+
+func TestProjectFileTurnsFollowSymlinksOff(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "settings.toml")
+	writeSettings(t, user, "[file_system]\nfollow_symlinks = true\n")
+	project := filepath.Join(dir, "project", ProjectFileName)
+	writeSettings(t, project, "[file_system]\nfollow_symlinks = false\n")
+
+	if !LoadFiles(user).FileSystem.FollowSymlinks {
+		t.Fatal("expected the user settings to follow symlinks")
+	}
+	settings, sources, _ := LoadWithSources(user, project)
+	if settings.FileSystem.FollowSymlinks {
+		t.Fatal("expected the project file to turn following symlinks off")
+	}
+	if sources["file_system.follow_symlinks"].Name != project {
+		t.Fatalf("expected follow_symlinks to come from %s, got %+v", project, sources["file_system.follow_symlinks"])
+	}
+}
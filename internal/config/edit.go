@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// Set returns the settings document data with key set to the text value,
+// parsed with ParseValue. An existing value is replaced where it stands and a
+// missing key is added to its table, so comments and layout are kept.
+func Set(data []byte, key, text string) ([]byte, error) {
+	value, err := ParseValue(key, text)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := EncodeValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	table, leaf := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		table, leaf = key[:i], key[i+1:]
+	}
+
+	var p unstable.Parser
+	p.Reset(data)
+
+	current := ""
+	insertAt := -1
+	firstTable := -1
+	for p.NextExpression() {
+		expr := p.Expression()
+		name, keyEnd := keyName(expr)
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			if firstTable < 0 {
+				firstTable = lineStart(data, keyEnd)
+			}
+			current = name
+			if expr.Kind == unstable.ArrayTable {
+				current = "[[" + name + "]]"
+			}
+			if current == table {
+				insertAt = lineEnd(data, keyEnd)
+			}
+		case unstable.KeyValue:
+			start, end := valueRange(data, expr, keyEnd)
+			full := name
+			if current != "" {
+				full = current + "." + name
+			}
+			if full == key {
+				var out bytes.Buffer
+				out.Write(data[:start])
+				out.WriteString(encoded)
+				out.Write(data[end:])
+				return out.Bytes(), nil
+			}
+			if current == table {
+				insertAt = lineEnd(data, end)
+			}
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, fmt.Errorf("cannot edit malformed settings: %w", err)
+	}
+
+	line := leaf + " = " + encoded + "\n"
+	if insertAt < 0 && table == "" {
+		insertAt = len(data)
+		if firstTable >= 0 {
+			insertAt = firstTable
+			line += "\n"
+		}
+	}
+	if insertAt < 0 {
+		var out bytes.Buffer
+		out.Write(data)
+		if len(data) > 0 {
+			if !bytes.HasSuffix(data, []byte("\n")) {
+				out.WriteByte('\n')
+			}
+			out.WriteByte('\n')
+		}
+		fmt.Fprintf(&out, "[%s]\n%s", table, line)
+		return out.Bytes(), nil
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:insertAt])
+	if insertAt > 0 && data[insertAt-1] != '\n' {
+		out.WriteByte('\n')
+	}
+	out.WriteString(line)
+	out.Write(data[insertAt:])
+	return out.Bytes(), nil
+}
+
+// EncodeValue formats a settings value the way the TOML encoder writes it.
+func EncodeValue(value any) (string, error) {
+	encoded, err := toml.Marshal(map[string]any{"v": value})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(encoded), "v = ")), nil
+}
+
+// keyName returns the dotted key of an expression and the offset just past it.
+func keyName(expr *unstable.Node) (string, int) {
+	var parts []string
+	end := 0
+	for it := expr.Key(); it.Next(); {
+		node := it.Node()
+		parts = append(parts, string(node.Data))
+		end = int(node.Raw.Offset + node.Raw.Length)
+	}
+	return strings.Join(parts, "."), end
+}
+
+// valueRange returns the byte range of a key/value expression's value. The
+// parser does not record ranges for arrays, inline tables and booleans, so
+// those are found after the key.
+func valueRange(data []byte, expr *unstable.Node, keyEnd int) (int, int) {
+	value := expr.Value()
+	switch value.Kind {
+	case unstable.Array, unstable.InlineTable, unstable.Bool:
+	default:
+		return int(value.Raw.Offset), int(value.Raw.Offset + value.Raw.Length)
+	}
+
+	start := keyEnd + bytes.IndexByte(data[keyEnd:], '=') + 1
+	for start < len(data) && (data[start] == ' ' || data[start] == '\t') {
+		start++
+	}
+	if value.Kind == unstable.Bool {
+		return start, start + len(value.Data)
+	}
+	return start, closingBracket(data, start) + 1
+}
+
+// closingBracket returns the offset of the bracket closing the one at start,
+// skipping strings and comments.
+func closingBracket(data []byte, start int) int {
+	depth := 0
+	for i := start; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '#':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case '"', '\'':
+			for i++; i < len(data) && data[i] != c; i++ {
+				if c == '"' && data[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+	return len(data) - 1
+}
+
+func lineStart(data []byte, offset int) int {
+	return bytes.LastIndexByte(data[:offset], '\n') + 1
+}
+
+func lineEnd(data []byte, offset int) int {
+	if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 {
+		return offset + i + 1
+	}
+	return len(data)
+}
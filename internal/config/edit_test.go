@@ -0,0 +1,119 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const editableSettings = `# prompt directories
+default_dir = [
+  "./testdata", # fixtures
+  "../prompts",
+] # trailing
+
+# File system settings
+[file_system]
+# extensions to scan
+extensions = [".md"]
+max_file_size_kb = 128 # KB
+
+[ui]
+truncate_length = 120
+`
+
+func TestSetReplacesValuesInPlace(t *testing.T) {
+	data := []byte(editableSettings)
+
+	data, err := Set(data, "default_dir", "~/prompts, ./work")
+	if err != nil {
+		t.Fatalf("Set(default_dir) error = %v", err)
+	}
+	data, err = Set(data, "file_system.max_file_size_kb", "256")
+	if err != nil {
+		t.Fatalf("Set(max_file_size_kb) error = %v", err)
+	}
+
+	want := strings.Replace(editableSettings, `[
+  "./testdata", # fixtures
+  "../prompts",
+]`, `['~/prompts', './work']`, 1)
+	want = strings.Replace(want, "max_file_size_kb = 128 # KB", "max_file_size_kb = 256 # KB", 1)
+	if string(data) != want {
+		t.Fatalf("unexpected document:\n%s", data)
+	}
+}
+
+func TestSetAddsMissingKeys(t *testing.T) {
+	data := []byte(editableSettings)
+
+	data, err := Set(data, "file_system.ignore_patterns", "*.tmp")
+	if err != nil {
+		t.Fatalf("Set(ignore_patterns) error = %v", err)
+	}
+	data, err = Set(data, "fuzzy_search.max_results", "5")
+	if err != nil {
+		t.Fatalf("Set(max_results) error = %v", err)
+	}
+	data, err = Set(data, "cache_dir", "/tmp/pm")
+	if err != nil {
+		t.Fatalf("Set(cache_dir) error = %v", err)
+	}
+
+	if !strings.Contains(string(data), "max_file_size_kb = 128 # KB\nignore_patterns = ['*.tmp']\n\n[ui]") {
+		t.Fatalf("expected ignore_patterns at the end of [file_system]:\n%s", data)
+	}
+	if !strings.HasSuffix(string(data), "\n\n[fuzzy_search]\nmax_results = 5\n") {
+		t.Fatalf("expected a new [fuzzy_search] table:\n%s", data)
+	}
+	if !strings.Contains(string(data), "] # trailing\ncache_dir = '/tmp/pm'\n") {
+		t.Fatalf("expected cache_dir after the top-level keys:\n%s", data)
+	}
+
+	var raw rawSettings
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("edited document does not parse: %v\n%s", err, data)
+	}
+	if raw.FuzzySearch.MaxResults != 5 || raw.CacheDir == nil || *raw.CacheDir != "/tmp/pm" {
+		t.Fatalf("unexpected decoded values %+v", raw)
+	}
+}
+
+func TestSetRejectsInvalidValues(t *testing.T) {
+	if _, err := Set(nil, "ui.truncate_length", "wide"); err == nil {
+		t.Fatal("expected error for a non-numeric value")
+	}
+	if _, err := Set(nil, "ui.colour", "red"); err == nil {
+		t.Fatal("expected error for an unknown key")
+	}
+	if _, err := Set(nil, "fuzzy_search.rank", "fastest"); err == nil {
+		t.Fatal("expected error for an unknown ranking preset")
+	}
+	if _, err := Set(nil, "fuzzy_search.tag_weight", "-2"); err == nil {
+		t.Fatal("expected error for a negative weight")
+	}
+}
+
+func TestSetWritesRankingValues(t *testing.T) {
+	data, err := Set(nil, "fuzzy_search.cutoff", "0.5")
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if data, err = Set(data, "fuzzy_search.rank", "content-first"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if string(data) != "[fuzzy_search]\ncutoff = 0.5\nrank = 'content-first'\n" {
+		t.Fatalf("unexpected settings:\n%s", data)
+	}
+}
+
+func TestSetReplacesBooleans(t *testing.T) {
+	data, err := Set([]byte("[file_system]\nfollow_symlinks = false # links\n"), "file_system.follow_symlinks", "true")
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if string(data) != "[file_system]\nfollow_symlinks = true # links\n" {
+		t.Fatalf("unexpected settings:\n%s", data)
+	}
+}
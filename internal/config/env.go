@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// applyEnv overrides settings with the PM_* environment variables named by
+// EnvName. PM_DEFAULT_DIR is a list separated like $PATH; other lists are
+// comma separated. Values that do not parse are reported and ignored.
+func applyEnv(settings *Settings, sources map[string]Source) []Issue {
+	var issues []Issue
+	for _, key := range Keys {
+		name := EnvName(key)
+		text := os.Getenv(name)
+		if text == "" {
+			continue
+		}
+
+		var value any
+		switch key {
+		case "default_dir":
+			var dirs []string
+			for _, dir := range filepath.SplitList(text) {
+				if dir != "" {
+					dirs = append(dirs, expandHome(dir))
+				}
+			}
+			if len(dirs) == 0 {
+				continue
+			}
+			value = dirs
+		case "cache_dir":
+			value = expandHome(text)
+		default:
+			parsed, err := ParseValue(key, text)
+			if err != nil {
+				issues = append(issues, Issue{Env: name, Key: key, Severity: SeverityError, Message: err.Error()})
+				continue
+			}
+			value = parsed
+		}
+
+		settings.set(key, value)
+		sources[key] = Source{Kind: SourceEnv, Name: name}
+	}
+	return issues
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAppliesEnvOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	writeSettings(t, path, "default_dir = \"from-file\"\n\n[fuzzy_search]\nmax_results = 5\n")
+
+	dirs := strings.Join([]string{"/mnt/prompts", "/srv/prompts"}, string(os.PathListSeparator))
+	t.Setenv("PM_DEFAULT_DIR", dirs)
+	t.Setenv("PM_FILE_SYSTEM_EXTENSIONS", ".md, .prompt")
+	t.Setenv("PM_FUZZY_SEARCH_MAX_RESULTS", "9")
+	t.Setenv("PM_UI_TRUNCATE_LENGTH", "")
+	t.Setenv("PM_FILE_SYSTEM_FOLLOW_SYMLINKS", "true")
+	t.Setenv("PM_FUZZY_SEARCH_CONTENT_WEIGHT", "2.5")
+
+	settings, sources, _ := LoadWithSources(path)
+
+	if len(settings.DefaultDirs) != 2 || settings.DefaultDirs[0] != "/mnt/prompts" || settings.DefaultDirs[1] != "/srv/prompts" {
+		t.Fatalf("expected PM_DEFAULT_DIR to win, got %v", settings.DefaultDirs)
+	}
+	if len(settings.FileSystem.Extensions) != 2 || settings.FileSystem.Extensions[1] != ".prompt" {
+		t.Fatalf("expected PM_FILE_SYSTEM_EXTENSIONS to win, got %v", settings.FileSystem.Extensions)
+	}
+	if settings.FuzzySearch.MaxResults != 9 {
+		t.Fatalf("expected PM_FUZZY_SEARCH_MAX_RESULTS to win, got %d", settings.FuzzySearch.MaxResults)
+	}
+	if settings.UI.TruncateLength != Defaults().UI.TruncateLength {
+		t.Fatalf("expected an empty variable to be ignored, got %d", settings.UI.TruncateLength)
+	}
+	if !settings.FileSystem.FollowSymlinks {
+		t.Fatal("expected PM_FILE_SYSTEM_FOLLOW_SYMLINKS to enable following links")
+	}
+	if got := settings.FuzzySearch.Ranking().Weights.Content; got != 2.5 {
+		t.Fatalf("expected PM_FUZZY_SEARCH_CONTENT_WEIGHT to win, got %v", got)
+	}
+	if got := sources["fuzzy_search.max_results"]; got != (Source{Kind: SourceEnv, Name: "PM_FUZZY_SEARCH_MAX_RESULTS"}) {
+		t.Fatalf("unexpected source %v", got)
+	}
+}
+
+func TestLoadStrictReportsBadEnvValues(t *testing.T) {
+	t.Setenv("PM_UI_TRUNCATE_LENGTH", "wide")
+
+	settings, issues, err := LoadStrict()
+	if err == nil {
+		t.Fatal("expected an error for an unparsable override")
+	}
+	if len(issues) != 1 || issues[0].Env != "PM_UI_TRUNCATE_LENGTH" {
+		t.Fatalf("expected one issue for PM_UI_TRUNCATE_LENGTH, got %+v", issues)
+	}
+	if !strings.HasPrefix(issues[0].String(), "$PM_UI_TRUNCATE_LENGTH: error:") {
+		t.Fatalf("unexpected issue text %q", issues[0].String())
+	}
+	if settings.UI.TruncateLength != Defaults().UI.TruncateLength {
+		t.Fatalf("expected the bad override to be ignored, got %d", settings.UI.TruncateLength)
+	}
+}
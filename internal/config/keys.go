@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hzionn/prompt-manager-cli/internal/ranking"
+)
+
+// Keys lists the settings keys in the order they appear in settings.toml.
+var Keys = []string{
+	"default_dir",
+	"cache_dir",
+	"file_system.extensions",
+	"file_system.ignore_patterns",
+	"file_system.max_file_size_kb",
+	"file_system.workers",
+	"file_system.follow_symlinks",
+	"fuzzy_search.max_results",
+	"fuzzy_search.rank",
+	"fuzzy_search.name_weight",
+	"fuzzy_search.alias_weight",
+	"fuzzy_search.tag_weight",
+	"fuzzy_search.meta_weight",
+	"fuzzy_search.content_weight",
+	"fuzzy_search.cutoff",
+	"fuzzy_search.content_window",
+	"ui.truncate_length",
+}
+
+// Source kinds, from lowest to highest precedence.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Source says where an effective setting came from.
+type Source struct {
+	Kind string // SourceDefault, SourceFile, SourceEnv or SourceFlag
+	Name string // the file, environment variable or flag; empty for defaults
+}
+
+func (s Source) String() string {
+	if s.Name == "" {
+		return s.Kind
+	}
+	return s.Kind + " " + s.Name
+}
+
+// Get returns the value of a settings key: a []string, string, int, float64
+// or bool. The ranking keys report the value in effect, taken from the preset
+// when they are not set.
+func (s Settings) Get(key string) (any, error) {
+	switch key {
+	case "default_dir":
+		return s.DefaultDirs, nil
+	case "cache_dir":
+		return s.CacheDir, nil
+	case "file_system.extensions":
+		return s.FileSystem.Extensions, nil
+	case "file_system.ignore_patterns":
+		return s.FileSystem.IgnorePatterns, nil
+	case "file_system.max_file_size_kb":
+		return s.FileSystem.MaxFileSizeKB, nil
+	case "file_system.workers":
+		return s.FileSystem.Workers, nil
+	case "file_system.follow_symlinks":
+		return s.FileSystem.FollowSymlinks, nil
+	case "fuzzy_search.max_results":
+		return s.FuzzySearch.MaxResults, nil
+	case "fuzzy_search.rank":
+		return s.FuzzySearch.Rank, nil
+	case "fuzzy_search.name_weight":
+		return s.FuzzySearch.Ranking().Weights.Name, nil
+	case "fuzzy_search.alias_weight":
+		return s.FuzzySearch.Ranking().Weights.Alias, nil
+	case "fuzzy_search.tag_weight":
+		return s.FuzzySearch.Ranking().Weights.Tag, nil
+	case "fuzzy_search.meta_weight":
+		return s.FuzzySearch.Ranking().Weights.Meta, nil
+	case "fuzzy_search.content_weight":
+		return s.FuzzySearch.Ranking().Weights.Content, nil
+	case "fuzzy_search.cutoff":
+		return s.FuzzySearch.Ranking().Cutoff, nil
+	case "fuzzy_search.content_window":
+		return s.FuzzySearch.Ranking().ContentWindow, nil
+	case "ui.truncate_length":
+		return s.UI.TruncateLength, nil
+	}
+	return nil, fmt.Errorf("unknown settings key %q", key)
+}
+
+// set assigns a value returned by ParseValue to key.
+func (s *Settings) set(key string, value any) {
+	switch key {
+	case "default_dir":
+		s.DefaultDirs = value.([]string)
+	case "cache_dir":
+		s.CacheDir = value.(string)
+	case "file_system.extensions":
+		s.FileSystem.Extensions = value.([]string)
+	case "file_system.ignore_patterns":
+		s.FileSystem.IgnorePatterns = value.([]string)
+	case "file_system.max_file_size_kb":
+		s.FileSystem.MaxFileSizeKB = value.(int)
+	case "file_system.workers":
+		s.FileSystem.Workers = value.(int)
+	case "file_system.follow_symlinks":
+		s.FileSystem.FollowSymlinks = value.(bool)
+	case "fuzzy_search.max_results":
+		s.FuzzySearch.MaxResults = value.(int)
+	case "fuzzy_search.rank":
+		s.FuzzySearch.Rank = value.(string)
+	case "fuzzy_search.name_weight":
+		v := value.(float64)
+		s.FuzzySearch.NameWeight = &v
+	case "fuzzy_search.alias_weight":
+		v := value.(float64)
+		s.FuzzySearch.AliasWeight = &v
+	case "fuzzy_search.tag_weight":
+		v := value.(float64)
+		s.FuzzySearch.TagWeight = &v
+	case "fuzzy_search.meta_weight":
+		v := value.(float64)
+		s.FuzzySearch.MetaWeight = &v
+	case "fuzzy_search.content_weight":
+		v := value.(float64)
+		s.FuzzySearch.ContentWeight = &v
+	case "fuzzy_search.cutoff":
+		v := value.(float64)
+		s.FuzzySearch.Cutoff = &v
+	case "fuzzy_search.content_window":
+		s.FuzzySearch.ContentWindow = value.(int)
+	case "ui.truncate_length":
+		s.UI.TruncateLength = value.(int)
+	}
+}
+
+// EnvName returns the environment variable that overrides key, for example
+// PM_FUZZY_SEARCH_MAX_RESULTS for fuzzy_search.max_results.
+func EnvName(key string) string {
+	return "PM_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// ParseValue converts text to the type of the key: a comma separated list for
+// list keys, a positive integer for integer keys, a number of at least zero
+// for weights, true or false for switches, a preset name for
+// fuzzy_search.rank and the text itself for the rest, which may be empty only
+// for cache_dir.
+func ParseValue(key, text string) (any, error) {
+	current, err := Defaults().Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch current.(type) {
+	case []string:
+		var values []string
+		for _, part := range strings.Split(text, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("%s needs at least one value", key)
+		}
+		return values, nil
+	case int:
+		n, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%s must be a positive integer, got %q", key, text)
+		}
+		return n, nil
+	case float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil || n < 0 || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("%s must be a number of at least zero, got %q", key, text)
+		}
+		return n, nil
+	case bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got %q", key, text)
+		}
+		return b, nil
+	}
+	if strings.TrimSpace(text) == "" && key != "cache_dir" {
+		return nil, fmt.Errorf("%s must not be empty", key)
+	}
+	if key == "fuzzy_search.rank" {
+		if _, ok := ranking.Preset(text); !ok {
+			return nil, unknownPresetError(text)
+		}
+	}
+	return text, nil
+}
+
+func unknownPresetError(name string) error {
+	return fmt.Errorf("fuzzy_search.rank must be one of %s, got %q", strings.Join(ranking.Names, ", "), name)
+}
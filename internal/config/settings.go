@@ -1,9 +1,10 @@
 package config
 
 import (
-	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/pelletier/go-toml/v2"
+	"github.com/hzionn/prompt-manager-cli/internal/ranking"
 )
 
 // Settings represents persisted configuration for the CLI.
@@ -13,6 +14,19 @@ type Settings struct {
 	FileSystem  FileSystemSettings  `toml:"file_system"`
 	FuzzySearch FuzzySearchSettings `toml:"fuzzy_search"`
 	UI          UISettings          `toml:"ui"`
+	Sources     []SourceSettings    `toml:"sources"`
+}
+
+// SourceSettings describe a named prompt directory from a [[sources]] table.
+// Unset discovery fields fall back to the [file_system] settings.
+type SourceSettings struct {
+	Name           string   `toml:"name"`
+	Path           string   `toml:"path"`
+	Extensions     []string `toml:"extensions"`
+	IgnorePatterns []string `toml:"ignore_patterns"`
+	MaxFileSizeKB  int      `toml:"max_file_size_kb"`
+	ReadOnly       bool     `toml:"read_only"`
+	Weight         float64  `toml:"weight"` // ranking multiplier; 0 means 1
 }
 
 // FileSystemSettings describe filesystem discovery behaviour.
@@ -20,11 +34,52 @@ type FileSystemSettings struct {
 	Extensions     []string `toml:"extensions"`
 	IgnorePatterns []string `toml:"ignore_patterns"`
 	MaxFileSizeKB  int      `toml:"max_file_size_kb"`
+	Workers        int      `toml:"workers"` // files parsed concurrently; 0 uses every CPU
+	FollowSymlinks bool     `toml:"follow_symlinks"`
 }
 
-// FuzzySearchSettings describe search behaviour.
+// FuzzySearchSettings describe search behaviour. Rank names a ranking preset;
+// the weights and cutoff replace the preset's values when they are set, zero
+// included, and the content window when it is above zero.
 type FuzzySearchSettings struct {
-	MaxResults int `toml:"max_results"`
+	MaxResults    int      `toml:"max_results"`
+	Rank          string   `toml:"rank"`
+	NameWeight    *float64 `toml:"name_weight"`
+	AliasWeight   *float64 `toml:"alias_weight"`
+	TagWeight     *float64 `toml:"tag_weight"`
+	MetaWeight    *float64 `toml:"meta_weight"`
+	ContentWeight *float64 `toml:"content_weight"`
+	Cutoff        *float64 `toml:"cutoff"`
+	ContentWindow int      `toml:"content_window"` // runes of content searched
+}
+
+// Ranking returns the ranking preset named by Rank, or the balanced preset
+// when it is unknown, with the values set here in place of the preset's.
+func (f FuzzySearchSettings) Ranking() ranking.Ranking {
+	r, ok := ranking.Preset(f.Rank)
+	if !ok {
+		r = ranking.Default()
+	}
+	overrides := []struct {
+		value  *float64
+		target *float64
+	}{
+		{f.NameWeight, &r.Weights.Name},
+		{f.AliasWeight, &r.Weights.Alias},
+		{f.TagWeight, &r.Weights.Tag},
+		{f.MetaWeight, &r.Weights.Meta},
+		{f.ContentWeight, &r.Weights.Content},
+		{f.Cutoff, &r.Cutoff},
+	}
+	for _, o := range overrides {
+		if o.value != nil {
+			*o.target = *o.value
+		}
+	}
+	if f.ContentWindow > 0 {
+		r.ContentWindow = f.ContentWindow
+	}
+	return r
 }
 
 // UISettings contains UI defaults.
@@ -33,65 +88,191 @@ type UISettings struct {
 }
 
 type rawSettings struct {
-	DefaultDirs interface{}         `toml:"default_dir"`
-	CacheDir    string              `toml:"cache_dir"`
-	FileSystem  FileSystemSettings  `toml:"file_system"`
-	FuzzySearch FuzzySearchSettings `toml:"fuzzy_search"`
-	UI          UISettings          `toml:"ui"`
+	DefaultDirs interface{}           `toml:"default_dir"`
+	CacheDir    *string               `toml:"cache_dir"` // "" disables the index
+	FileSystem  rawFileSystemSettings `toml:"file_system"`
+	FuzzySearch FuzzySearchSettings   `toml:"fuzzy_search"`
+	UI          UISettings            `toml:"ui"`
+	Sources     []SourceSettings      `toml:"sources"`
 }
 
-// Load reads settings from the provided path. Missing or malformed files fall back to defaults.
-func Load(path string) Settings {
-	defaults := Settings{
+// rawFileSystemSettings is FileSystemSettings as read from a file, where
+// follow_symlinks = false can turn off what an earlier file turned on.
+type rawFileSystemSettings struct {
+	Extensions     []string `toml:"extensions"`
+	IgnorePatterns []string `toml:"ignore_patterns"`
+	MaxFileSizeKB  int      `toml:"max_file_size_kb"`
+	Workers        int      `toml:"workers"`
+	FollowSymlinks *bool    `toml:"follow_symlinks"`
+}
+
+// Defaults returns the built-in settings used when no file overrides them.
+func Defaults() Settings {
+	return Settings{
 		DefaultDirs: []string{"./prompts"},
-		CacheDir:    "./.pm-cache",
+		CacheDir:    DefaultCacheDir(),
 		FileSystem: FileSystemSettings{
 			Extensions:     []string{".md", ".txt"},
 			IgnorePatterns: []string{".DS_Store"},
 			MaxFileSizeKB:  128,
 		},
-		FuzzySearch: FuzzySearchSettings{MaxResults: 20},
+		FuzzySearch: FuzzySearchSettings{MaxResults: 20, Rank: ranking.Balanced},
 		UI:          UISettings{TruncateLength: 120},
 	}
+}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return defaults
-	}
+// Load reads settings from the provided path and applies the PM_* environment
+// overrides. Missing or malformed files fall back to defaults.
+func Load(path string) Settings {
+	return LoadFiles(path)
+}
 
-	var raw rawSettings
-	if err := toml.Unmarshal(data, &raw); err != nil {
-		return defaults
-	}
+// LoadFiles applies each settings file on top of the defaults in order, so
+// later files override the keys they set, followed by the PM_* environment
+// overrides. Missing or malformed files and unparsable environment values are
+// skipped; use LoadStrict to find out why. Relative directories in a project
+// file (see ProjectFileName) are resolved against the directory that contains
+// it.
+func LoadFiles(paths ...string) Settings {
+	settings, _, _ := load(paths)
+	return settings
+}
 
-	settings := defaults
+// LoadWithSources is LoadFiles that also reports where each effective key
+// came from, along with the issues LoadStrict would return.
+func LoadWithSources(paths ...string) (Settings, map[string]Source, []Issue) {
+	return load(paths)
+}
+
+// apply copies the keys set in the file onto settings and returns their
+// names. Relative directories are joined to base when it is not empty.
+func (raw rawSettings) apply(settings *Settings, base string) []string {
+	var set []string
 
 	// Handle default_dir which can be string or []string
 	defaultDirs := parseStringOrSlice(raw.DefaultDirs)
 	if len(defaultDirs) > 0 {
+		for i, dir := range defaultDirs {
+			defaultDirs[i] = resolveDir(dir, base)
+		}
 		settings.DefaultDirs = defaultDirs
+		set = append(set, "default_dir")
 	}
 
-	if raw.CacheDir != "" {
-		settings.CacheDir = raw.CacheDir
+	if raw.CacheDir != nil {
+		settings.CacheDir = ""
+		if *raw.CacheDir != "" {
+			settings.CacheDir = resolveDir(*raw.CacheDir, base)
+		}
+		set = append(set, "cache_dir")
 	}
 	if len(raw.FileSystem.Extensions) > 0 {
 		settings.FileSystem.Extensions = raw.FileSystem.Extensions
+		set = append(set, "file_system.extensions")
 	}
 	if len(raw.FileSystem.IgnorePatterns) > 0 {
 		settings.FileSystem.IgnorePatterns = raw.FileSystem.IgnorePatterns
+		set = append(set, "file_system.ignore_patterns")
 	}
 	if raw.FileSystem.MaxFileSizeKB > 0 {
 		settings.FileSystem.MaxFileSizeKB = raw.FileSystem.MaxFileSizeKB
+		set = append(set, "file_system.max_file_size_kb")
+	}
+	if raw.FileSystem.Workers > 0 {
+		settings.FileSystem.Workers = raw.FileSystem.Workers
+		set = append(set, "file_system.workers")
+	}
+	if raw.FileSystem.FollowSymlinks != nil {
+		settings.FileSystem.FollowSymlinks = *raw.FileSystem.FollowSymlinks
+		set = append(set, "file_system.follow_symlinks")
 	}
 	if raw.FuzzySearch.MaxResults > 0 {
 		settings.FuzzySearch.MaxResults = raw.FuzzySearch.MaxResults
+		set = append(set, "fuzzy_search.max_results")
+	}
+	if _, ok := ranking.Preset(raw.FuzzySearch.Rank); ok {
+		settings.FuzzySearch.Rank = raw.FuzzySearch.Rank
+		set = append(set, "fuzzy_search.rank")
+	}
+	weights := []struct {
+		key    string
+		value  *float64
+		target **float64
+	}{
+		{"fuzzy_search.name_weight", raw.FuzzySearch.NameWeight, &settings.FuzzySearch.NameWeight},
+		{"fuzzy_search.alias_weight", raw.FuzzySearch.AliasWeight, &settings.FuzzySearch.AliasWeight},
+		{"fuzzy_search.tag_weight", raw.FuzzySearch.TagWeight, &settings.FuzzySearch.TagWeight},
+		{"fuzzy_search.meta_weight", raw.FuzzySearch.MetaWeight, &settings.FuzzySearch.MetaWeight},
+		{"fuzzy_search.content_weight", raw.FuzzySearch.ContentWeight, &settings.FuzzySearch.ContentWeight},
+		{"fuzzy_search.cutoff", raw.FuzzySearch.Cutoff, &settings.FuzzySearch.Cutoff},
+	}
+	for _, w := range weights {
+		if w.value != nil && *w.value >= 0 {
+			*w.target = w.value
+			set = append(set, w.key)
+		}
+	}
+	if raw.FuzzySearch.ContentWindow > 0 {
+		settings.FuzzySearch.ContentWindow = raw.FuzzySearch.ContentWindow
+		set = append(set, "fuzzy_search.content_window")
 	}
 	if raw.UI.TruncateLength > 0 {
 		settings.UI.TruncateLength = raw.UI.TruncateLength
+		set = append(set, "ui.truncate_length")
 	}
+	if len(raw.Sources) > 0 {
+		settings.Sources = mergeSources(settings.Sources, raw.Sources, base)
+		set = append(set, "sources")
+	}
+	return set
+}
 
-	return settings
+// mergeSources adds the sources of a later file, replacing earlier sources of
+// the same name. Sources without a valid name or a path are left out; pm
+// config validate reports them.
+func mergeSources(current, added []SourceSettings, base string) []SourceSettings {
+	merged := append([]SourceSettings(nil), current...)
+	for _, source := range added {
+		if !validSourceName(source.Name) || source.Path == "" {
+			continue
+		}
+		source.Path = resolveDir(source.Path, base)
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == source.Name {
+				merged[i] = source
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, source)
+		}
+	}
+	return merged
+}
+
+// Source returns the configured source with the given name.
+func (s Settings) Source(name string) (SourceSettings, bool) {
+	for _, source := range s.Sources {
+		if source.Name == name {
+			return source, true
+		}
+	}
+	return SourceSettings{}, false
+}
+
+// validSourceName reports whether name can prefix prompt IDs.
+func validSourceName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, ":/\\ \t")
+}
+
+func resolveDir(dir, base string) string {
+	dir = expandHome(dir)
+	if base == "" || filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(base, dir)
 }
 
 // parseStringOrSlice handles values that can be either a string or an array of strings.
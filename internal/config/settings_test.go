@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/hzionn/prompt-manager-cli/internal/ranking"
 )
 
 func TestLoadDefaultsWhenMissing(t *testing.T) {
@@ -51,3 +53,162 @@ max_results = 5
 		t.Fatalf("expected MaxResults 5, got %d", settings.FuzzySearch.MaxResults)
 	}
 }
+
+func TestLoadWithSourcesRecordsFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.toml")
+	if err := os.WriteFile(path, []byte("[ui]\ntruncate_length = 80\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, sources, _ := LoadWithSources(path)
+
+	if got := sources["ui.truncate_length"]; got != (Source{Kind: SourceFile, Name: path}) {
+		t.Fatalf("expected truncate_length from %s, got %v", path, got)
+	}
+	if got := sources["default_dir"]; got.Kind != SourceDefault {
+		t.Fatalf("expected default_dir from defaults, got %v", got)
+	}
+}
+
+func TestLoadFilesMergesSourcesByName(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "settings.toml")
+	writeSettings(t, user, `
+[[sources]]
+name = "team"
+path = "/srv/prompts"
+read_only = true
+weight = 0.5
+
+[[sources]]
+name = "vault"
+path = "/home/me/vault"
+extensions = [".md", ".prompt"]
+`)
+	projectDir := filepath.Join(dir, "project")
+	project := filepath.Join(projectDir, ProjectFileName)
+	writeSettings(t, project, `
+[[sources]]
+name = "vault"
+path = "/mnt/vault"
+
+[[sources]]
+name = "project"
+path = "prompts"
+max_file_size_kb = 16
+`)
+
+	settings := LoadFiles(user, project)
+
+	if len(settings.Sources) != 3 {
+		t.Fatalf("expected 3 sources, got %+v", settings.Sources)
+	}
+	if team, _ := settings.Source("team"); !team.ReadOnly || team.Weight != 0.5 {
+		t.Fatalf("unexpected team source %+v", team)
+	}
+	if vault, _ := settings.Source("vault"); vault.Path != "/mnt/vault" || len(vault.Extensions) != 0 {
+		t.Fatalf("expected the project file to replace vault, got %+v", vault)
+	}
+	if p, _ := settings.Source("project"); p.Path != filepath.Join(projectDir, "prompts") || p.MaxFileSizeKB != 16 {
+		t.Fatalf("unexpected project source %+v", p)
+	}
+}
+
+func TestFuzzySearchRankingOverridesPreset(t *testing.T) {
+	if got, want := Defaults().FuzzySearch.Ranking(), ranking.Default(); got != want {
+		t.Fatalf("expected the balanced preset by default, got %+v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	writeSettings(t, path, "[fuzzy_search]\nrank = \"name-first\"\ncontent_weight = 2\ncutoff = 0.75\n")
+
+	settings, sources, _ := LoadWithSources(path)
+	want := mustPreset(t, ranking.NameFirst)
+	want.Weights.Content = 2
+	want.Cutoff = 0.75
+	if got := settings.FuzzySearch.Ranking(); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	for _, key := range []string{"fuzzy_search.rank", "fuzzy_search.content_weight", "fuzzy_search.cutoff"} {
+		if sources[key].Kind != SourceFile {
+			t.Fatalf("expected %s to come from the file, got %+v", key, sources[key])
+		}
+	}
+	if sources["fuzzy_search.name_weight"].Kind != SourceDefault {
+		t.Fatalf("expected name_weight to stay the default, got %+v", sources["fuzzy_search.name_weight"])
+	}
+}
+
+func TestFuzzySearchRankingKeepsExplicitZeroes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	writeSettings(t, path, "[fuzzy_search]\ncontent_weight = 0\ncutoff = 0.0\n")
+
+	settings, sources, _ := LoadWithSources(path)
+	want := ranking.Default()
+	want.Weights.Content = 0
+	want.Cutoff = 0
+	if got := settings.FuzzySearch.Ranking(); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if sources["fuzzy_search.content_weight"].Kind != SourceFile || sources["fuzzy_search.cutoff"].Kind != SourceFile {
+		t.Fatalf("expected the zeroes to come from the file, got %+v", sources)
+	}
+
+	t.Setenv("PM_FUZZY_SEARCH_NAME_WEIGHT", "0")
+	settings = Load(path)
+	if got := settings.FuzzySearch.Ranking().Weights.Name; got != 0 {
+		t.Fatalf("expected PM_FUZZY_SEARCH_NAME_WEIGHT=0 to win, got %v", got)
+	}
+}
+
+func TestGetReportsPresetValues(t *testing.T) {
+	settings := Defaults()
+	if got, _ := settings.Get("fuzzy_search.name_weight"); got != 5.0 {
+		t.Fatalf("expected the balanced name weight, got %v", got)
+	}
+	if got, _ := settings.Get("fuzzy_search.content_window"); got != 2048 {
+		t.Fatalf("expected the balanced content window, got %v", got)
+	}
+
+	settings.FuzzySearch.Rank = ranking.NameFirst
+	if got, _ := settings.Get("fuzzy_search.content_weight"); got != 0.0 {
+		t.Fatalf("expected the name-first content weight, got %v", got)
+	}
+}
+
+func mustPreset(t *testing.T, name string) ranking.Ranking {
+	t.Helper()
+	r, ok := ranking.Preset(name)
+	if !ok {
+		t.Fatalf("missing preset %q", name)
+	}
+	return r
+}
+
+func TestLoadFilesSkipsInvalidSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	writeSettings(t, path, `
+[[sources]]
+path = "/a"
+
+[[sources]]
+path = "/b"
+
+[[sources]]
+name = "bad name"
+path = "/c"
+
+[[sources]]
+name = "nopath"
+
+[[sources]]
+name = "good"
+path = "/d"
+`)
+
+	settings := LoadFiles(path)
+	if len(settings.Sources) != 1 || settings.Sources[0].Name != "good" {
+		t.Fatalf("expected only the valid source, got %+v", settings.Sources)
+	}
+}
@@ -0,0 +1,575 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+
+	"github.com/hzionn/prompt-manager-cli/internal/ignore"
+	"github.com/hzionn/prompt-manager-cli/internal/ranking"
+)
+
+// Severity ranks a validation issue.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found in a settings file or environment variable. Line
+// and Column are 1-based and zero when the problem has no position in the file.
+type Issue struct {
+	File     string
+	Env      string // set instead of File for environment overrides
+	Line     int
+	Column   int
+	Key      string
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	location := i.File
+	if i.Env != "" {
+		location = "$" + i.Env
+	}
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, i.Severity, i.Message)
+}
+
+// ValidationError is returned by LoadStrict when a settings file has errors.
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Issues) == 1 {
+		return e.Issues[0].String()
+	}
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		lines = append(lines, issue.String())
+	}
+	return fmt.Sprintf("%d errors in settings:\n%s", len(e.Issues), strings.Join(lines, "\n"))
+}
+
+// LoadStrict is LoadFiles for callers that want to know what went wrong. It
+// returns every issue found, warnings included: unreadable or malformed
+// files, values of the wrong type, unknown keys, directories that do not
+// exist and environment overrides that do not parse. Files with syntax or
+// type errors are skipped as LoadFiles would skip them. The error is a
+// *ValidationError holding the error-severity issues, or nil when there are
+// none.
+func LoadStrict(paths ...string) (Settings, []Issue, error) {
+	settings, _, issues := load(paths)
+
+	var errs []Issue
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	if len(errs) > 0 {
+		return settings, issues, &ValidationError{Issues: errs}
+	}
+	return settings, issues, nil
+}
+
+func load(paths []string) (Settings, map[string]Source, []Issue) {
+	settings := Defaults()
+	sources := make(map[string]Source, len(Keys))
+	for _, key := range Keys {
+		sources[key] = Source{Kind: SourceDefault}
+	}
+	var issues, dirIssues []Issue
+	docs := make(map[string][]byte, len(paths))
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			issues = append(issues, Issue{File: path, Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+
+		docs[path] = data
+
+		raw, fileIssues, ok := decodeStrict(path, data)
+		issues = append(issues, fileIssues...)
+		if !ok {
+			continue
+		}
+
+		base := ""
+		if filepath.Base(path) == ProjectFileName {
+			base = filepath.Dir(path)
+		}
+		for _, key := range raw.apply(&settings, base) {
+			sources[key] = Source{Kind: SourceFile, Name: path}
+		}
+
+		for i, source := range raw.Sources {
+			if source.Path == "" {
+				continue
+			}
+			key := fmt.Sprintf("sources[%d]", i)
+			pos := positionOf(data, key)
+			for _, issue := range missingDirIssues(Issue{File: path, Line: pos.Line, Column: pos.Column}, []string{resolveDir(source.Path, base)}) {
+				issue.Key = key
+				issue.Message = fmt.Sprintf("source %q: %s", source.Name, issue.Message)
+				issues = append(issues, issue)
+			}
+		}
+
+		if raw.DefaultDirs != nil {
+			// Only the directories of the last file setting default_dir are used.
+			pos := positionOf(data, "default_dir")
+			dirIssues = missingDirIssues(Issue{File: path, Line: pos.Line, Column: pos.Column}, settings.DefaultDirs)
+		}
+	}
+	issues = append(issues, applyEnv(&settings, sources)...)
+	if settings.FuzzySearch.Ranking().Weights.IsZero() {
+		issues = append(issues, zeroWeightsIssue(sources, docs))
+	}
+	if source := sources["default_dir"]; source.Kind == SourceEnv {
+		dirIssues = missingDirIssues(Issue{Env: source.Name}, settings.DefaultDirs)
+	}
+	return settings, sources, append(issues, dirIssues...)
+}
+
+// zeroWeightsIssue reports weights that are all zero. No preset has them, so
+// at least one was set in a file or the environment; the issue points at one
+// of those.
+func zeroWeightsIssue(sources map[string]Source, docs map[string][]byte) Issue {
+	issue := Issue{Severity: SeverityError, Message: "every fuzzy_search weight is zero, so no prompt can match a query"}
+	for _, key := range []string{
+		"fuzzy_search.name_weight",
+		"fuzzy_search.alias_weight",
+		"fuzzy_search.tag_weight",
+		"fuzzy_search.meta_weight",
+		"fuzzy_search.content_weight",
+	} {
+		switch source := sources[key]; source.Kind {
+		case SourceFile:
+			pos := positionOf(docs[source.Name], key)
+			issue.File, issue.Env, issue.Line, issue.Column, issue.Key = source.Name, "", pos.Line, pos.Column, key
+		case SourceEnv:
+			issue.File, issue.Env, issue.Line, issue.Column, issue.Key = "", source.Name, 0, 0, key
+		}
+	}
+	return issue
+}
+
+// decodeStrict decodes a settings file, reporting unknown keys as warnings and
+// invalid values as errors. ok is false when the file cannot be used at all.
+// Values of the wrong type are left out of the decoding, so the rest of the
+// file is still checked, but they make the file unusable too.
+func decodeStrict(path string, data []byte) (raw rawSettings, issues []Issue, ok bool) {
+	issues, data = typeIssues(path, data)
+	ok = len(issues) == 0
+	mistyped := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		mistyped[issue.Key] = true
+	}
+
+	decoder := toml.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(&raw)
+	var strictErr *toml.StrictMissingError
+	var decodeErr *toml.DecodeError
+	switch {
+	case errors.As(err, &strictErr):
+		for _, e := range strictErr.Errors {
+			line, column := e.Position()
+			key := strings.Join(e.Key(), ".")
+			issues = append(issues, Issue{File: path, Line: line, Column: column, Key: key, Severity: SeverityWarning, Message: fmt.Sprintf("unknown key %q", key)})
+		}
+	case errors.As(err, &decodeErr):
+		line, column := decodeErr.Position()
+		message := strings.TrimPrefix(decodeErr.Error(), "toml: ")
+		return raw, append(issues, Issue{File: path, Line: line, Column: column, Key: strings.Join(decodeErr.Key(), "."), Severity: SeverityError, Message: message}), false
+	case err != nil:
+		return raw, append(issues, Issue{File: path, Severity: SeverityError, Message: err.Error()}), false
+	}
+
+	issue := func(key string, severity Severity, format string, args ...any) {
+		pos := positionOf(data, key)
+		issues = append(issues, Issue{File: path, Line: pos.Line, Column: pos.Column, Key: key, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch dirs := raw.DefaultDirs.(type) {
+	case nil, string:
+	case []interface{}:
+		for i, dir := range dirs {
+			if _, ok := dir.(string); !ok {
+				issue("default_dir", SeverityError, "default_dir[%d] must be a string, got %s", i, tomlType(dir))
+			}
+		}
+	default:
+		issue("default_dir", SeverityError, "default_dir must be a string or an array of strings, got %s", tomlType(dirs))
+	}
+
+	for _, pattern := range raw.FileSystem.IgnorePatterns {
+		if err := ignore.Validate(pattern); err != nil {
+			issue("file_system.ignore_patterns", SeverityError, "invalid ignore pattern %q: %v", pattern, err)
+		}
+	}
+
+	names := make(map[string]struct{}, len(raw.Sources))
+	for i, source := range raw.Sources {
+		label := fmt.Sprintf("sources[%d]", i)
+		switch {
+		case mistyped[label+".name"]:
+		case source.Name == "":
+			issue(label, SeverityError, "%s has no name", label)
+		case !validSourceName(source.Name):
+			issue(label, SeverityError, "source name %q must not contain ':', slashes or spaces", source.Name)
+		}
+		if _, dup := names[source.Name]; dup && source.Name != "" {
+			issue(label, SeverityError, "source %q is defined more than once", source.Name)
+		}
+		names[source.Name] = struct{}{}
+		if source.Path == "" && !mistyped[label+".path"] {
+			issue(label, SeverityError, "%s has no path", label)
+		}
+		if source.Weight < 0 {
+			issue(label, SeverityError, "source %q has a negative weight", source.Name)
+		}
+		if source.MaxFileSizeKB < 0 {
+			issue(label, SeverityWarning, "source %q: max_file_size_kb must be positive; the default is used", source.Name)
+		}
+		for _, pattern := range source.IgnorePatterns {
+			if err := ignore.Validate(pattern); err != nil {
+				issue(label, SeverityError, "source %q: invalid ignore pattern %q: %v", source.Name, pattern, err)
+			}
+		}
+	}
+
+	positive := []struct {
+		key   string
+		value int
+	}{
+		{"file_system.max_file_size_kb", raw.FileSystem.MaxFileSizeKB},
+		{"file_system.workers", raw.FileSystem.Workers},
+		{"fuzzy_search.max_results", raw.FuzzySearch.MaxResults},
+		{"fuzzy_search.content_window", raw.FuzzySearch.ContentWindow},
+		{"ui.truncate_length", raw.UI.TruncateLength},
+	}
+	for _, field := range positive {
+		if field.value < 0 {
+			issue(field.key, SeverityWarning, "%s must be positive; the default is used", field.key)
+		}
+	}
+
+	if raw.FuzzySearch.Rank != "" {
+		if _, ok := ranking.Preset(raw.FuzzySearch.Rank); !ok {
+			issue("fuzzy_search.rank", SeverityError, "%v", unknownPresetError(raw.FuzzySearch.Rank))
+		}
+	}
+	weights := []struct {
+		key   string
+		value *float64
+	}{
+		{"fuzzy_search.name_weight", raw.FuzzySearch.NameWeight},
+		{"fuzzy_search.alias_weight", raw.FuzzySearch.AliasWeight},
+		{"fuzzy_search.tag_weight", raw.FuzzySearch.TagWeight},
+		{"fuzzy_search.meta_weight", raw.FuzzySearch.MetaWeight},
+		{"fuzzy_search.content_weight", raw.FuzzySearch.ContentWeight},
+		{"fuzzy_search.cutoff", raw.FuzzySearch.Cutoff},
+	}
+	for _, field := range weights {
+		if field.value != nil && *field.value < 0 {
+			issue(field.key, SeverityWarning, "%s must not be negative; the preset's value is used", field.key)
+		}
+	}
+
+	return raw, issues, ok
+}
+
+// typeIssues reports the values in data whose TOML type does not fit the
+// setting they are for, and returns data with those key/value pairs blanked
+// out. Newlines are kept, so positions in the rest of the file do not move.
+// Unknown keys are left to the decoder.
+func typeIssues(path string, data []byte) ([]Issue, []byte) {
+	var p unstable.Parser
+	p.Reset(data)
+
+	var issues []Issue
+	var clean []byte
+	var table []string
+	var label string
+	counts := make(map[string]int)
+	for p.NextExpression() {
+		expr := p.Expression()
+		var parts []string
+		var first, last *unstable.Node
+		for it := expr.Key(); it.Next(); {
+			if first == nil {
+				first = it.Node()
+			}
+			last = it.Node()
+			parts = append(parts, string(last.Data))
+		}
+		if first == nil {
+			continue
+		}
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table, label = parts, strings.Join(parts, ".")
+			if expr.Kind == unstable.ArrayTable {
+				label = fmt.Sprintf("%s[%d]", label, counts[label])
+				counts[strings.Join(parts, ".")]++
+			}
+		case unstable.KeyValue:
+			t, ok := settingType(append(append([]string(nil), table...), parts...))
+			if !ok {
+				continue
+			}
+			key := strings.Join(parts, ".")
+			if label != "" {
+				key = label + "." + key
+			}
+			messages := typeMismatches(expr.Value(), t, key)
+			if len(messages) == 0 {
+				continue
+			}
+			pos := p.Shape(first.Raw).Start
+			for _, message := range messages {
+				issues = append(issues, Issue{File: path, Line: pos.Line, Column: pos.Column, Key: key, Severity: SeverityError, Message: message})
+			}
+
+			if clean == nil {
+				clean = append([]byte(nil), data...)
+			}
+			start := int(first.Raw.Offset)
+			_, end := valueRange(data, expr, int(last.Raw.Offset+last.Raw.Length))
+			for i := start; i < end; i++ {
+				if clean[i] != '\n' && clean[i] != '\r' {
+					clean[i] = ' '
+				}
+			}
+		}
+	}
+	if clean == nil {
+		return issues, data
+	}
+	return issues, clean
+}
+
+// settingType returns the type of the rawSettings field that the TOML key
+// sets, looking through pointers and into the tables of table arrays.
+func settingType(parts []string) (reflect.Type, bool) {
+	t := reflect.TypeOf(rawSettings{})
+	for _, part := range parts {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, false
+		}
+		field, ok := tomlField(t, part)
+		if !ok {
+			return nil, false
+		}
+		t = field.Type
+	}
+	return t, true
+}
+
+func tomlField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if tag, _, _ := strings.Cut(field.Tag.Get("toml"), ","); tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// typeMismatches describes where the value node cannot be decoded into t.
+// Interface fields accept anything; decodeStrict checks those itself.
+func typeMismatches(n *unstable.Node, t reflect.Type, key string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var want string
+	var fits bool
+	switch t.Kind() {
+	case reflect.Interface:
+		return nil
+	case reflect.String:
+		want, fits = "a string", n.Kind == unstable.String
+	case reflect.Bool:
+		want, fits = "a boolean", n.Kind == unstable.Bool
+	case reflect.Int:
+		want, fits = "an integer", n.Kind == unstable.Integer
+	case reflect.Float64:
+		want, fits = "a number", n.Kind == unstable.Integer || n.Kind == unstable.Float
+	case reflect.Slice:
+		if n.Kind != unstable.Array {
+			want = "an array"
+			break
+		}
+		var messages []string
+		i := 0
+		for it := n.Children(); it.Next(); i++ {
+			messages = append(messages, typeMismatches(it.Node(), t.Elem(), fmt.Sprintf("%s[%d]", key, i))...)
+		}
+		return messages
+	case reflect.Struct:
+		if n.Kind != unstable.InlineTable {
+			want = "a table"
+			break
+		}
+		var messages []string
+		for it := n.Children(); it.Next(); {
+			kv := it.Node()
+			field, sub := t, key
+			for k := kv.Key(); k.Next() && field.Kind() == reflect.Struct; {
+				name := string(k.Node().Data)
+				f, ok := tomlField(field, name)
+				if !ok {
+					field = nil
+					break
+				}
+				field, sub = f.Type, sub+"."+name
+				for field.Kind() == reflect.Pointer {
+					field = field.Elem()
+				}
+			}
+			if field != nil {
+				messages = append(messages, typeMismatches(kv.Value(), field, sub)...)
+			}
+		}
+		return messages
+	default:
+		return nil
+	}
+	if fits {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s must be %s, got %s", key, want, nodeType(n))}
+}
+
+// nodeType names the TOML type of a value node the way tomlType does.
+func nodeType(n *unstable.Node) string {
+	switch n.Kind {
+	case unstable.String:
+		return "string"
+	case unstable.Integer:
+		return "integer"
+	case unstable.Float:
+		return "float"
+	case unstable.Bool:
+		return "boolean"
+	case unstable.Array:
+		return "array"
+	case unstable.InlineTable:
+		return "table"
+	case unstable.DateTime, unstable.LocalDateTime, unstable.LocalDate, unstable.LocalTime:
+		return "datetime"
+	}
+	return n.Kind.String()
+}
+
+// missingDirIssues warns about dirs that are not directories, reporting them
+// at the location of the default_dir setting given by at.
+func missingDirIssues(at Issue, dirs []string) []Issue {
+	var issues []Issue
+	for _, dir := range dirs {
+		issue := at
+		issue.Key = "default_dir"
+		issue.Severity = SeverityWarning
+		info, err := os.Stat(dir)
+		switch {
+		case err != nil:
+			issue.Message = fmt.Sprintf("directory %s does not exist", dir)
+		case !info.IsDir():
+			issue.Message = fmt.Sprintf("%s is not a directory", dir)
+		default:
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// positionOf returns where the dotted key is set in the document, or the zero
+// position when it is not set. Entries of a table array are addressed by
+// index, as in "sources[1]" or "sources[1].path".
+func positionOf(data []byte, key string) unstable.Position {
+	var p unstable.Parser
+	p.Reset(data)
+
+	var table string
+	counts := make(map[string]int)
+	for p.NextExpression() {
+		expr := p.Expression()
+		var parts []string
+		var first *unstable.Node
+		for it := expr.Key(); it.Next(); {
+			if first == nil {
+				first = it.Node()
+			}
+			parts = append(parts, string(it.Node().Data))
+		}
+		name := strings.Join(parts, ".")
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			if expr.Kind == unstable.ArrayTable {
+				n := counts[name]
+				counts[name]++
+				name = fmt.Sprintf("%s[%d]", name, n)
+			}
+			table = name
+			if name == key && first != nil {
+				return p.Shape(first.Raw).Start
+			}
+		case unstable.KeyValue:
+			if table != "" {
+				name = table + "." + name
+			}
+			if name == key && first != nil {
+				return p.Shape(first.Raw).Start
+			}
+		}
+	}
+	return unstable.Position{}
+}
+
+func tomlType(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "table"
+	case time.Time, toml.LocalDate, toml.LocalDateTime, toml.LocalTime:
+		return "datetime"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadStrictReportsSyntaxErrorPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	writeSettings(t, path, "[fuzzy_search]\nmax_results = 5,\n")
+
+	settings, issues, err := LoadStrict(path)
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(issues) != 1 || issues[0].Line != 2 || issues[0].Column == 0 {
+		t.Fatalf("expected one issue on line 2, got %+v", issues)
+	}
+	if settings.FuzzySearch.MaxResults != Defaults().FuzzySearch.MaxResults {
+		t.Fatalf("expected defaults for a malformed file, got %d", settings.FuzzySearch.MaxResults)
+	}
+}
+
+func TestLoadStrictReportsEveryTypeError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	writeSettings(t, path, `file_system = { workers = 2.5 }
+
+[fuzzy_search]
+max_results = "many"
+rank = "fastest"
+follow = true
+
+[ui]
+truncate_length = [
+  120,
+]
+
+[[sources]]
+name = "team"
+path = 3
+`)
+
+	settings, issues, err := LoadStrict(path)
+	if err == nil {
+		t.Fatal("expected an error for values of the wrong type")
+	}
+	want := []struct {
+		line    int
+		message string
+	}{
+		{1, "file_system.workers must be an integer, got float"},
+		{4, "fuzzy_search.max_results must be an integer, got string"},
+		{9, "ui.truncate_length must be an integer, got array"},
+		{15, "sources[0].path must be a string, got integer"},
+		{6, `unknown key "fuzzy_search.follow"`},
+		{5, "fuzzy_search.rank must be one of"},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("expected %d issues, got %+v", len(want), issues)
+	}
+	for i, w := range want {
+		if issues[i].Line != w.line || !strings.HasPrefix(issues[i].Message, w.message) {
+			t.Fatalf("issue %d: expected %q on line %d, got %+v", i, w.message, w.line, issues[i])
+		}
+	}
+	if settings.FuzzySearch.MaxResults != Defaults().FuzzySearch.MaxResults {
+		t.Fatalf("expected a file with type errors to be skipped, got %+v", settings.FuzzySearch)
+	}
+}
+
+func TestLoadStrictReportsInvalidValues(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.toml")
+	writeSettings(t, path, `default_dir = ["`+filepath.ToSlash(dir)+`", 3]
+
+[file_system]
+ignore_patterns = ["[unclosed", "[z-a].md"]
+`)
+
+	settings, issues, err := LoadStrict(path)
+	if err == nil {
+		t.Fatal("expected error for invalid values")
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", issues)
+	}
+	if issues[0].Key != "default_dir" || issues[0].Line != 1 || !strings.Contains(issues[0].Message, "integer") {
+		t.Fatalf("unexpected default_dir issue %+v", issues[0])
+	}
+	// An unclosed bracket is a literal in gitignore syntax; an inverted range
+	// cannot match anything.
+	if issues[1].Key != "file_system.ignore_patterns" || issues[1].Line != 4 || !strings.Contains(issues[1].Message, "[z-a].md") {
+		t.Fatalf("unexpected ignore_patterns issue %+v", issues[1])
+	}
+	if len(settings.DefaultDirs) != 1 {
+		t.Fatalf("expected the string entry to be kept, got %v", settings.DefaultDirs)
+	}
+}
+
+func TestLoadStrictValidatesRanking(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	writeSettings(t, path, "[fuzzy_search]\nrank = \"fastest\"\nname_weight = -1.0\n")
+
+	settings, issues, err := LoadStrict(path)
+	if err == nil {
+		t.Fatal("expected an error for an unknown preset")
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", issues)
+	}
+	if issues[0].Key != "fuzzy_search.rank" || issues[0].Line != 2 || issues[0].Severity != SeverityError || !strings.Contains(issues[0].Message, "balanced, name-first, content-first") {
+		t.Fatalf("unexpected rank issue %+v", issues[0])
+	}
+	if issues[1].Key != "fuzzy_search.name_weight" || issues[1].Line != 3 || issues[1].Severity != SeverityWarning {
+		t.Fatalf("unexpected weight issue %+v", issues[1])
+	}
+	if settings.FuzzySearch.Rank != "balanced" || settings.FuzzySearch.NameWeight != nil {
+		t.Fatalf("expected invalid values to be ignored, got %+v", settings.FuzzySearch)
+	}
+}
+
+func TestLoadStrictRejectsAllZeroWeights(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	writeSettings(t, path, "[fuzzy_search]\nrank = \"name-first\"\nname_weight = 0\nalias_weight = 0\ntag_weight = 0\nmeta_weight = 0\n")
+
+	_, issues, err := LoadStrict(path)
+	if err == nil || len(issues) != 1 {
+		t.Fatalf("expected one error for all-zero weights, got %v %+v", err, issues)
+	}
+	if issues[0].Key != "fuzzy_search.meta_weight" || issues[0].Line != 6 || issues[0].Severity != SeverityError {
+		t.Fatalf("expected the issue at the last weight set, got %+v", issues[0])
+	}
+
+	t.Setenv("PM_FUZZY_SEARCH_META_WEIGHT", "1")
+	if _, issues, err := LoadStrict(path); err != nil || len(issues) != 0 {
+		t.Fatalf("expected a non-zero weight to be accepted, got %v %+v", err, issues)
+	}
+}
+
+func TestLoadStrictWarnsAboutUnknownKeysAndMissingDirs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.toml")
+	missing := filepath.Join(dir, "missing")
+	writeSettings(t, path, `default_dir = "`+filepath.ToSlash(missing)+`"
+
+[fuzzy_search]
+max_result = 5
+`)
+
+	_, issues, err := LoadStrict(path)
+	if err != nil {
+		t.Fatalf("expected warnings only, got %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 warnings, got %+v", issues)
+	}
+	if issues[0].Key != "fuzzy_search.max_result" || issues[0].Line != 4 || issues[0].Severity != SeverityWarning {
+		t.Fatalf("unexpected unknown key issue %+v", issues[0])
+	}
+	if issues[1].Key != "default_dir" || !strings.Contains(issues[1].Message, "does not exist") {
+		t.Fatalf("unexpected directory issue %+v", issues[1])
+	}
+}
+
+func TestLoadStrictAcceptsValidFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.toml")
+	writeSettings(t, path, `default_dir = ["`+filepath.ToSlash(dir)+`"]
+
+[ui]
+truncate_length = 80
+`)
+
+	settings, issues, err := LoadStrict(path)
+	if err != nil || len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v %+v", err, issues)
+	}
+	if settings.UI.TruncateLength != 80 {
+		t.Fatalf("expected truncate_length 80, got %d", settings.UI.TruncateLength)
+	}
+}
+
+func TestLoadStrictValidatesSources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.toml")
+	writeSettings(t, path, `[[sources]]
+name = "team"
+path = "`+filepath.ToSlash(dir)+`"
+
+[[sources]]
+name = "bad:name"
+path = "`+filepath.ToSlash(filepath.Join(dir, "missing"))+`"
+weight = -1
+`)
+
+	_, issues, err := LoadStrict(path)
+	if err == nil {
+		t.Fatal("expected errors for the second source")
+	}
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", issues)
+	}
+	for _, issue := range issues {
+		if issue.Key != "sources[1]" || issue.Line != 5 {
+			t.Fatalf("expected issues at sources[1] on line 5, got %+v", issue)
+		}
+	}
+}
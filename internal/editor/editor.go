@@ -0,0 +1,39 @@
+package editor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoEditor indicates that no editor is configured in the environment.
+var ErrNoEditor = errors.New("no editor configured: set $VISUAL or $EDITOR")
+
+// Command builds the command that opens path in the editor named by $VISUAL,
+// falling back to $EDITOR. Either variable may include arguments, such as
+// "code --wait".
+func Command(path string) (*exec.Cmd, error) {
+	fields := strings.Fields(os.Getenv("VISUAL"))
+	if len(fields) == 0 {
+		fields = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(fields) == 0 {
+		return nil, ErrNoEditor
+	}
+
+	args := append(fields[1:], path)
+	return exec.Command(fields[0], args...), nil
+}
+
+// Open runs the editor on path attached to the current terminal and waits for it to exit.
+func Open(path string) error {
+	cmd, err := Command(path)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,46 @@
+package editor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCommandSplitsEditorArguments(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+
+	cmd, err := Command("/tmp/prompt.md")
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+
+	want := []string{"code", "--wait", "/tmp/prompt.md"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Fatalf("Command().Args = %v, want %v", cmd.Args, want)
+	}
+}
+
+func TestCommandPrefersVisual(t *testing.T) {
+	t.Setenv("VISUAL", "gvim -f")
+	t.Setenv("EDITOR", "vi")
+
+	cmd, err := Command("notes.md")
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+
+	want := []string{"gvim", "-f", "notes.md"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Fatalf("Command().Args = %v, want %v", cmd.Args, want)
+	}
+}
+
+func TestCommandWithoutEditor(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+
+	if _, err := Command("/tmp/prompt.md"); !errors.Is(err, ErrNoEditor) {
+		t.Fatalf("expected ErrNoEditor, got %v", err)
+	}
+}
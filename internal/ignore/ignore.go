@@ -0,0 +1,210 @@
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// FileNames are the per-directory ignore files read while walking a prompt
+// directory, in order of precedence from lowest to highest.
+var FileNames = []string{".gitignore", ".pmignore"}
+
+// Matcher decides whether paths are ignored using gitignore rules: patterns
+// containing a slash are anchored to the directory that declares them, others
+// match a name at any depth; "**" spans directories, a trailing "/" limits a
+// rule to directories and a leading "!" re-includes a path. Later rules take
+// precedence over earlier ones.
+type Matcher struct {
+	rules []rule
+}
+
+type rule struct {
+	base    string // slash-separated directory the rule applies under; "" for the root
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// New returns a matcher for patterns relative to the root.
+func New(patterns []string) *Matcher {
+	m := &Matcher{}
+	m.Add("", patterns)
+	return m
+}
+
+// Add appends patterns declared in dir, a slash-separated path relative to
+// the root. Blank lines and comments are skipped.
+func (m *Matcher) Add(dir string, patterns []string) {
+	dir = strings.Trim(dir, "/")
+	if dir == "." {
+		dir = ""
+	}
+	for _, line := range patterns {
+		if r, ok := parseRule(dir, line); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// AddFile appends the rules of the ignore file at filePath, declared in dir. A
+// missing file is not an error.
+func (m *Matcher) AddFile(dir, filePath string) error {
+	file, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	m.Add(dir, lines)
+	return nil
+}
+
+// Match reports whether rel, a slash-separated path relative to the root, is
+// ignored. isDir says whether it names a directory.
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	rel = strings.Trim(path.Clean("/"+rel), "/")
+
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		target := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			target = rel[len(r.base)+1:]
+		}
+		if r.pattern.MatchString(target) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// Validate reports why pattern cannot be used as an ignore rule, such as a
+// character class with an inverted range. Patterns that are blank or comments
+// are valid and match nothing.
+func Validate(pattern string) error {
+	_, _, err := compileRule("", pattern)
+	return err
+}
+
+// parseRule parses one line of an ignore file. Lines that are blank,
+// comments or do not compile yield no rule.
+func parseRule(base, line string) (rule, bool) {
+	r, ok, err := compileRule(base, line)
+	return r, ok && err == nil
+}
+
+func compileRule(base, line string) (rule, bool, error) {
+	line = strings.TrimRight(line, "\r")
+	line = trimTrailingSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false, nil
+	}
+
+	r := rule{base: base}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false, nil
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	pattern, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return rule{}, false, err
+	}
+	r.pattern = pattern
+	return r, true, nil
+}
+
+// trimTrailingSpace removes trailing spaces unless they are escaped.
+func trimTrailingSpace(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			atStart := i == 0 || glob[i-1] == '/'
+			rest := glob[i+2:]
+			switch {
+			case atStart && strings.HasPrefix(rest, "/"):
+				// "**/" matches zero or more leading directories.
+				b.WriteString("(?:.*/)?")
+				i += 2
+			case atStart && rest == "":
+				// A trailing "/**" matches everything inside.
+				b.WriteString(".*")
+				i++
+			default:
+				b.WriteString("[^/]*")
+				i++
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
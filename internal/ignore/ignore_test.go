@@ -0,0 +1,109 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	m := New([]string{
+		"# comment",
+		"",
+		".DS_Store",
+		"*.tmp",
+		"/root-only.md",
+		"templates/drafts/**",
+		".obsidian/",
+		"**/archive/*.md",
+		"docs/**/old",
+		"*.log",
+		"!keep.log",
+		`\!bang.md`,
+	})
+
+	cases := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{".DS_Store", false, true},
+		{"nested/.DS_Store", false, true},
+		{"notes/a.tmp", false, true},
+		{"root-only.md", false, true},
+		{"nested/root-only.md", false, false},
+		{"templates/drafts/idea.md", false, true},
+		{"templates/drafts/deep/idea.md", false, true},
+		{"templates/drafts", true, false},
+		{"templates/final.md", false, false},
+		{".obsidian", true, true},
+		{"vault/.obsidian", true, true},
+		{".obsidian", false, false},
+		{"archive/a.md", false, true},
+		{"x/y/archive/a.md", false, true},
+		{"x/archive/sub/a.md", false, false},
+		{"docs/old", true, true},
+		{"docs/a/b/old", true, true},
+		{"debug.log", false, true},
+		{"keep.log", false, false},
+		{"!bang.md", false, true},
+		{"review.md", false, false},
+	}
+	for _, tc := range cases {
+		if got := m.Match(tc.path, tc.isDir); got != tc.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tc.path, tc.isDir, got, tc.want)
+		}
+	}
+}
+
+func TestAddScopesRulesToTheirDirectory(t *testing.T) {
+	m := New([]string{"*.md", "!keep/*.md"})
+	m.Add("work", []string{"!*.md", "/private.md"})
+
+	if !m.Match("personal/notes.md", false) {
+		t.Fatal("expected root rule to apply outside work/")
+	}
+	if m.Match("keep/notes.md", false) {
+		t.Fatal("expected negation to re-include keep/notes.md")
+	}
+	if m.Match("work/notes.md", false) {
+		t.Fatal("expected work/ rules to override the root rule")
+	}
+	if !m.Match("work/private.md", false) || m.Match("work/sub/private.md", false) {
+		t.Fatal("expected the anchored rule to match only work/private.md")
+	}
+}
+
+func TestAddFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".pmignore")
+	if err := os.WriteFile(path, []byte("drafts/\r\n*.bak  \n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	m := New(nil)
+	if err := m.AddFile("sub", path); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if err := m.AddFile("", filepath.Join(dir, "missing")); err != nil {
+		t.Fatalf("AddFile() of a missing file error = %v", err)
+	}
+
+	if !m.Match("sub/drafts", true) || !m.Match("sub/x/old.bak", false) {
+		t.Fatal("expected rules from the file to apply under sub/")
+	}
+	if m.Match("drafts", true) {
+		t.Fatal("expected rules from sub/ not to apply at the root")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, pattern := range []string{"*.md", "[unclosed", "drafts/**", "# comment", ""} {
+		if err := Validate(pattern); err != nil {
+			t.Fatalf("Validate(%q) error = %v", pattern, err)
+		}
+	}
+	if err := Validate("[z-a].md"); err == nil {
+		t.Fatal("expected an inverted character range to be rejected")
+	}
+}
@@ -0,0 +1,105 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DiagnosticKind classifies a problem found while loading prompts.
+type DiagnosticKind string
+
+const (
+	// DiagnosticSkipped is a file left out on purpose, such as one over the
+	// size limit. It is informational and never fails a strict load.
+	DiagnosticSkipped DiagnosticKind = "skipped"
+	// DiagnosticFrontMatter is a file whose front matter could not be used.
+	// The prompt is still loaded, with the file as plain content when the
+	// YAML does not parse or without variables when they are invalid.
+	DiagnosticFrontMatter DiagnosticKind = "front-matter"
+	// DiagnosticPermission is a file or directory pm may not read.
+	DiagnosticPermission DiagnosticKind = "permission"
+	// DiagnosticUnreadable is any other file or directory that failed to load.
+	DiagnosticUnreadable DiagnosticKind = "unreadable"
+)
+
+// Diagnostic describes a problem with one file or directory that loading
+// worked around.
+type Diagnostic struct {
+	Kind    DiagnosticKind
+	Path    string
+	Line    int // line in the file, when known
+	Message string
+	Err     error // the underlying error; nil for skipped files
+}
+
+func (d Diagnostic) String() string {
+	location := d.Path
+	if d.Line > 0 {
+		location += ":" + strconv.Itoa(d.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, d.Kind, d.Message)
+}
+
+// Fatal reports whether the diagnostic fails a strict load.
+func (d Diagnostic) Fatal() bool {
+	return d.Kind != DiagnosticSkipped
+}
+
+// FrontMatterError reports front matter that is not valid YAML or declares
+// invalid variables.
+type FrontMatterError struct {
+	Path    string
+	Line    int // line in the file, when known
+	Message string
+}
+
+func (e *FrontMatterError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: front matter: %s", e.Path, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: front matter: %s", e.Path, e.Message)
+}
+
+// yamlLine finds the "line N: " prefix used by yaml.v3 and by parseVariables.
+// yaml.v3 leaves it out for problems on the first line.
+var yamlLine = regexp.MustCompile(`line (\d+): `)
+
+// newFrontMatterError converts err, whose line numbers count from the first
+// line of the front matter, to a FrontMatterError with a line in the file.
+// fallback is used when err has no line of its own.
+func newFrontMatterError(path string, err error, fallback int) *FrontMatterError {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	line := fallback
+	if loc := yamlLine.FindStringSubmatchIndex(message); loc != nil {
+		n, _ := strconv.Atoi(message[loc[2]:loc[3]])
+		line = n
+		message = message[loc[1]:]
+	}
+	if line > 0 {
+		// The opening "---" is the first line of the file.
+		line++
+	}
+	return &FrontMatterError{Path: path, Line: line, Message: message}
+}
+
+func diagnose(path string, err error) Diagnostic {
+	var frontMatter *FrontMatterError
+	if errors.As(err, &frontMatter) {
+		return Diagnostic{Kind: DiagnosticFrontMatter, Path: path, Line: frontMatter.Line, Message: frontMatter.Message, Err: err}
+	}
+
+	message := err.Error()
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		message = pathErr.Err.Error()
+	}
+	kind := DiagnosticUnreadable
+	if errors.Is(err, fs.ErrPermission) {
+		kind = DiagnosticPermission
+	}
+	return Diagnostic{Kind: kind, Path: path, Message: message, Err: err}
+}
@@ -0,0 +1,93 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadWithDiagnosticsKeepsGoing(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a-good.md"), "---\ntags: [ok]\n---\nFine.\n")
+	writeFile(t, filepath.Join(root, "b-yaml.md"), "---\ntitle: ok\nsummary: a: b\n---\nBody.\n")
+	writeFile(t, filepath.Join(root, "c-vars.md"), "---\ntitle: ok\nvariables: 3\n---\nBody {{x}}.\n")
+	writeFile(t, filepath.Join(root, "d-large.md"), strings.Repeat("x", 3*1024))
+
+	prompts, diagnostics, err := LoadWithDiagnostics([]Source{{Path: root}}, Options{MaxFileSize: 2 * 1024})
+	if err != nil {
+		t.Fatalf("LoadWithDiagnostics() error = %v", err)
+	}
+
+	var names []string
+	for _, p := range prompts {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, " ") != "a-good b-yaml c-vars" {
+		t.Fatalf("expected the files with bad front matter to load, got %v", names)
+	}
+	if !strings.HasPrefix(prompts[1].Content, "---\ntitle: ok") {
+		t.Fatalf("expected bad YAML to load as plain content, got %q", prompts[1].Content)
+	}
+
+	var got []string
+	for _, d := range diagnostics {
+		got = append(got, strings.TrimPrefix(d.String(), root+string(filepath.Separator)))
+	}
+	want := []string{
+		"b-yaml.md:3: front-matter: mapping values are not allowed in this context",
+		"c-vars.md:3: front-matter: variables must be a mapping or a list",
+		"d-large.md: skipped: 3 KB is over the 2 KB size limit",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLoadWithDiagnosticsReportsUnreadableFiles(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "open.md"), "Open.")
+	writeFile(t, filepath.Join(root, "secret.md"), "Secret.")
+	if err := os.Chmod(filepath.Join(root, "secret.md"), 0); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+
+	prompts, diagnostics, err := LoadWithDiagnostics([]Source{{Path: root}}, Options{})
+	if err != nil {
+		t.Fatalf("LoadWithDiagnostics() error = %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].Name != "open" {
+		t.Fatalf("expected only open.md to load, got %d prompts", len(prompts))
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Kind != DiagnosticPermission || !errors.Is(diagnostics[0].Err, os.ErrPermission) {
+		t.Fatalf("expected a permission diagnostic, got %+v", diagnostics)
+	}
+
+	if _, _, err := LoadWithDiagnostics([]Source{{Path: root}}, Options{Strict: true}); !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("expected a strict load to fail with the permission error, got %v", err)
+	}
+}
+
+func TestStrictLoadFailsOnBadFrontMatter(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "bad.md"), "---\ntitle: ok\nsummary: a: b\n---\nBody.\n")
+	writeFile(t, filepath.Join(root, "large.md"), strings.Repeat("x", 2048))
+
+	_, _, err := LoadWithDiagnostics([]Source{{Path: root}}, Options{Strict: true})
+	var frontMatter *FrontMatterError
+	if !errors.As(err, &frontMatter) || frontMatter.Line != 3 {
+		t.Fatalf("expected a front matter error on line 3, got %v", err)
+	}
+
+	// Skipped files are not errors, even in strict mode.
+	if err := os.Remove(filepath.Join(root, "bad.md")); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, _, err := LoadWithDiagnostics([]Source{{Path: root}}, Options{Strict: true, MaxFileSize: 1024}); err != nil {
+		t.Fatalf("expected a skipped file not to fail a strict load, got %v", err)
+	}
+}
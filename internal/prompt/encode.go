@@ -0,0 +1,31 @@
+package prompt
+
+import (
+	"bytes"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Header is the front matter written for new prompt files.
+type Header struct {
+	Summary string   `yaml:"summary,omitempty"`
+	Tags    []string `yaml:"tags,omitempty"`
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+// Encode formats a prompt file with the header as YAML front matter. An empty
+// header produces a file without front matter.
+func Encode(h Header, content string) ([]byte, error) {
+	var buf bytes.Buffer
+	if h.Summary != "" || len(h.Tags) > 0 || len(h.Aliases) > 0 {
+		front, err := yaml.Marshal(h)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(front)
+		buf.WriteString("---\n")
+	}
+	buf.WriteString(content)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,47 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeRoundTripsThroughParser(t *testing.T) {
+	header := Header{
+		Summary: "Review: a pull request",
+		Tags:    []string{"review", "go"},
+		Aliases: []string{"pr review"},
+	}
+
+	data, err := Encode(header, "# Review\n")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	p, err := buildPrompt("review.md", data)
+	if err != nil {
+		t.Fatalf("buildPrompt() error = %v", err)
+	}
+
+	if p.Content != "# Review\n" {
+		t.Fatalf("unexpected content %q", p.Content)
+	}
+	if !reflect.DeepEqual(p.Tags, header.Tags) {
+		t.Fatalf("unexpected tags %v", p.Tags)
+	}
+	if p.FrontMatter["summary"] != header.Summary {
+		t.Fatalf("unexpected summary %#v", p.FrontMatter["summary"])
+	}
+	if aliases, ok := p.FrontMatter["aliases"].([]any); !ok || len(aliases) != 1 || aliases[0] != "pr review" {
+		t.Fatalf("unexpected aliases %#v", p.FrontMatter["aliases"])
+	}
+}
+
+func TestEncodeWithoutHeader(t *testing.T) {
+	data, err := Encode(Header{}, "Plain\n")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if string(data) != "Plain\n" {
+		t.Fatalf("unexpected encoding %q", data)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !unix
+
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// fileKey identifies a directory independently of the path it is reached by.
+// Without device and inode numbers the resolved path stands in for them.
+type fileKey struct {
+	path string
+}
+
+func keyOf(path string, _ os.FileInfo) (fileKey, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileKey{}, err
+	}
+	abs, err := filepath.Abs(resolved)
+	if err != nil {
+		return fileKey{}, err
+	}
+	return fileKey{path: abs}, nil
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileKey identifies a directory independently of the path it is reached by.
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+func keyOf(path string, info os.FileInfo) (fileKey, error) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, fmt.Errorf("%s: no device and inode available", path)
+	}
+	return fileKey{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, nil
+}
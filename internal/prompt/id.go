@@ -0,0 +1,136 @@
+package prompt
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// NotFoundError reports a prompt reference that matches nothing.
+type NotFoundError struct {
+	Ref string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("prompt %q not found", e.Ref)
+}
+
+// AmbiguousError reports a short name or relative path that matches prompts
+// in more than one place. Candidates holds their qualified IDs.
+type AmbiguousError struct {
+	Ref        string
+	Candidates []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("prompt %q is ambiguous, use one of: %s", e.Ref, strings.Join(e.Candidates, ", "))
+}
+
+// Resolve finds the prompt a reference names. A reference is a qualified ID
+// such as "work:code/review", a path relative to its root such as
+// "code/review", or a bare name. Matching is case-insensitive; relative paths
+// and names that match more than one prompt return an *AmbiguousError.
+func Resolve(prompts []Prompt, ref string) (Prompt, error) {
+	for _, p := range prompts {
+		if p.ID != "" && strings.EqualFold(p.ID, ref) {
+			return p, nil
+		}
+	}
+
+	var matches []Prompt
+	for _, p := range prompts {
+		if strings.EqualFold(p.Name, ref) || (strings.Contains(ref, "/") && strings.EqualFold(relativeID(p.ID), ref)) {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Prompt{}, &NotFoundError{Ref: ref}
+	case 1:
+		return matches[0], nil
+	}
+	candidates := make([]string, 0, len(matches))
+	for _, p := range matches {
+		candidates = append(candidates, p.ID)
+	}
+	return Prompt{}, &AmbiguousError{Ref: ref, Candidates: candidates}
+}
+
+// Collisions returns the prompts that share their name with another prompt,
+// keyed by the lowercased name.
+func Collisions(prompts []Prompt) map[string][]Prompt {
+	byName := make(map[string][]Prompt)
+	for _, p := range prompts {
+		key := strings.ToLower(p.Name)
+		byName[key] = append(byName[key], p)
+	}
+	for key, group := range byName {
+		if len(group) < 2 {
+			delete(byName, key)
+		}
+	}
+	return byName
+}
+
+// sourceNames returns the ID prefix of each source: its name, or the base
+// name of its directory. Derived names already taken are numbered in order
+// ("prompts", "prompts-2") so that IDs stay unique.
+func sourceNames(sources []Source) []string {
+	names := make([]string, len(sources))
+	used := make(map[string]int)
+	for _, source := range sources {
+		if source.Name != "" {
+			used[source.Name]++
+		}
+	}
+	for i, source := range sources {
+		if source.Name != "" {
+			names[i] = source.Name
+			continue
+		}
+		name := filepath.Base(source.Path)
+		if abs, err := filepath.Abs(source.Path); err == nil {
+			name = filepath.Base(abs)
+		}
+		used[name]++
+		if n := used[name]; n > 1 {
+			name += "-" + strconv.Itoa(n)
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// promptID builds the qualified ID of the file at path under root: the root
+// alias and the slash-separated path relative to it, without the extension.
+func promptID(alias, root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	rel = strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+	return alias + ":" + rel
+}
+
+// disambiguateIDs keeps the extension on IDs that would otherwise be shared by
+// files differing only in extension, such as review.md and review.txt.
+func disambiguateIDs(prompts []Prompt) {
+	count := make(map[string]int, len(prompts))
+	for _, p := range prompts {
+		count[strings.ToLower(p.ID)]++
+	}
+	for i, p := range prompts {
+		if count[strings.ToLower(p.ID)] > 1 {
+			prompts[i].ID += filepath.Ext(p.Path)
+		}
+	}
+}
+
+func relativeID(id string) string {
+	if i := strings.Index(id, ":"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
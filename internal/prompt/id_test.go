@@ -0,0 +1,136 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadCollidingRoots(t *testing.T) []Prompt {
+	t.Helper()
+	base := t.TempDir()
+	work := filepath.Join(base, "work")
+	personal := filepath.Join(base, "personal")
+	if err := os.MkdirAll(filepath.Join(work, "code"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.MkdirAll(personal, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	writeFile(t, filepath.Join(work, "code", "review.md"), "Work review")
+	writeFile(t, filepath.Join(personal, "review.md"), "Personal review")
+	writeFile(t, filepath.Join(personal, "notes.md"), "Notes")
+	writeFile(t, filepath.Join(personal, "notes.txt"), "Plain notes")
+
+	prompts, err := LoadFromDirs([]string{work, personal}, Options{Extensions: []string{".md", ".txt"}})
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	return prompts
+}
+
+func TestLoadFromDirsAssignsQualifiedIDs(t *testing.T) {
+	prompts := loadCollidingRoots(t)
+
+	var ids []string
+	for _, p := range prompts {
+		ids = append(ids, p.ID)
+	}
+	want := "work:code/review personal:notes.md personal:notes.txt personal:review"
+	if strings.Join(ids, " ") != want {
+		t.Fatalf("expected IDs %q, got %q", want, strings.Join(ids, " "))
+	}
+}
+
+func TestResolve(t *testing.T) {
+	prompts := loadCollidingRoots(t)
+
+	for ref, want := range map[string]string{
+		"work:code/review": "Work review",
+		"PERSONAL:review":  "Personal review",
+		"code/review":      "Work review",
+	} {
+		p, err := Resolve(prompts, ref)
+		if err != nil {
+			t.Fatalf("Resolve(%q) error = %v", ref, err)
+		}
+		if p.Content != want {
+			t.Fatalf("Resolve(%q) = %q, want %q", ref, p.Content, want)
+		}
+	}
+
+	_, err := Resolve(prompts, "review")
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected AmbiguousError, got %v", err)
+	}
+	if strings.Join(ambiguous.Candidates, ",") != "work:code/review,personal:review" {
+		t.Fatalf("unexpected candidates %v", ambiguous.Candidates)
+	}
+
+	_, err = Resolve(prompts, "missing")
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected NotFoundError, got %v", err)
+	}
+}
+
+func TestSourceNamesNumberSharedNames(t *testing.T) {
+	got := sourceNames([]Source{{Path: "a/prompts"}, {Path: "b/prompts"}, {Name: "other", Path: "c"}, {Path: "d/other"}})
+	if strings.Join(got, ",") != "prompts,prompts-2,other,other-2" {
+		t.Fatalf("unexpected names %v", got)
+	}
+}
+
+func TestExpandResolvesQualifiedIncludes(t *testing.T) {
+	prompts := loadCollidingRoots(t)
+	p := Prompt{Name: "wrapper", Content: "Start\n{{> work:code/review}}"}
+
+	expanded, err := Expand(p, prompts)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if expanded.Content != "Start\nWork review" {
+		t.Fatalf("unexpected content %q", expanded.Content)
+	}
+
+	if _, err := Expand(Prompt{Name: "wrapper", Content: "{{> review}}"}, prompts); err == nil {
+		t.Fatal("expected an error for an ambiguous include")
+	}
+}
+
+func TestLoadFromSourcesAppliesSourceSettings(t *testing.T) {
+	base := t.TempDir()
+	shared := filepath.Join(base, "shared")
+	vault := filepath.Join(base, "vault")
+	for _, dir := range []string{shared, vault} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+	}
+	writeFile(t, filepath.Join(shared, "review.md"), "Shared review")
+	writeFile(t, filepath.Join(shared, "draft.md"), "Draft")
+	writeFile(t, filepath.Join(vault, "journal.prompt"), "Journal")
+	writeFile(t, filepath.Join(vault, "ideas.md"), "Ideas")
+
+	prompts, err := LoadFromSources([]Source{
+		{Name: "team", Path: shared, IgnorePatterns: []string{"draft.md"}, ReadOnly: true, Weight: 0.5},
+		{Path: vault, Extensions: []string{".prompt"}},
+	}, Options{Extensions: []string{".md"}})
+	if err != nil {
+		t.Fatalf("LoadFromSources() error = %v", err)
+	}
+
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 prompts, got %d", len(prompts))
+	}
+	team, journal := prompts[0], prompts[1]
+	if team.ID != "team:review" || team.Source != "team" || !team.ReadOnly || team.Weight != 0.5 {
+		t.Fatalf("unexpected team prompt %+v", team)
+	}
+	if journal.ID != "vault:journal" || journal.ReadOnly {
+		t.Fatalf("unexpected vault prompt %+v", journal)
+	}
+}
@@ -0,0 +1,126 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var includePattern = regexp.MustCompile(`\{\{>\s*([^{}]+?)\s*\}\}`)
+
+// IncludeError reports an include that could not be resolved, along with the
+// chain of prompts that led to it.
+type IncludeError struct {
+	Chain []string
+	Cycle bool
+}
+
+func (e *IncludeError) Error() string {
+	chain := strings.Join(e.Chain, " -> ")
+	if e.Cycle {
+		return fmt.Sprintf("include cycle: %s", chain)
+	}
+	return fmt.Sprintf("included prompt %q not found: %s", e.Chain[len(e.Chain)-1], chain)
+}
+
+// FindByName returns the first prompt whose name matches case-insensitively.
+// Use Resolve to accept qualified IDs and detect ambiguous names.
+func FindByName(prompts []Prompt, name string) (Prompt, bool) {
+	for _, p := range prompts {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Prompt{}, false
+}
+
+// Expand inlines every {{> name}} include in the prompt content, resolving
+// names and IDs against library with Resolve. Includes may nest; the
+// variables declared by included prompts are added after the prompt's own
+// declarations.
+func Expand(p Prompt, library []Prompt) (Prompt, error) {
+	content, vars, err := expandIncludes(p, library, []string{p.Name}, []string{includeKey(p)})
+	if err != nil {
+		return Prompt{}, err
+	}
+
+	expanded := p
+	expanded.Content = content
+	expanded.Variables = vars
+	return expanded, nil
+}
+
+// expandIncludes expands the includes of p. chain holds the references that
+// led to p, for error messages, and stack the identities of those prompts,
+// for cycle detection.
+func expandIncludes(p Prompt, library []Prompt, chain, stack []string) (string, []Variable, error) {
+	vars := append([]Variable(nil), p.Variables...)
+	var expandErr error
+
+	content := includePattern.ReplaceAllStringFunc(p.Content, func(match string) string {
+		if expandErr != nil {
+			return match
+		}
+
+		name := includePattern.FindStringSubmatch(match)[1]
+		path := append(append([]string(nil), chain...), name)
+
+		included, err := Resolve(library, name)
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) {
+			expandErr = &IncludeError{Chain: path}
+			return match
+		}
+		if err != nil {
+			expandErr = err
+			return match
+		}
+
+		key := includeKey(included)
+		for _, ancestor := range stack {
+			if ancestor == key {
+				expandErr = &IncludeError{Chain: path, Cycle: true}
+				return match
+			}
+		}
+
+		inner, innerVars, err := expandIncludes(included, library, path, append(append([]string(nil), stack...), key))
+		if err != nil {
+			expandErr = err
+			return match
+		}
+		vars = mergeVariables(vars, innerVars)
+		return strings.TrimRight(inner, "\r\n")
+	})
+
+	if expandErr != nil {
+		return "", nil, expandErr
+	}
+	return content, vars, nil
+}
+
+// includeKey identifies a prompt for cycle detection: by its file when it has
+// one, by name otherwise.
+func includeKey(p Prompt) string {
+	if p.Path != "" {
+		return p.Path
+	}
+	return strings.ToLower(p.Name)
+}
+
+func mergeVariables(vars, extra []Variable) []Variable {
+	for _, v := range extra {
+		declared := false
+		for _, existing := range vars {
+			if existing.Name == v.Name {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
@@ -0,0 +1,53 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRenderExpandsNestedIncludes(t *testing.T) {
+	library := []Prompt{
+		{Name: "house-style", Content: "Be concise.\n{{> signature}}\n"},
+		{Name: "signature", Content: "Signed, {{team}}.\n", Variables: []Variable{{Name: "team", Type: VariableString, Default: "Platform"}}},
+	}
+	p := Prompt{Name: "review", Content: "{{> House-Style}}\nReview {{language}} code."}
+
+	got, err := Render(p, RenderOptions{Values: map[string]string{"language": "Go"}, Library: library})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := "Be concise.\nSigned, Platform.\nReview Go code."
+	if got != want {
+		t.Fatalf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestExpandDetectsCycles(t *testing.T) {
+	library := []Prompt{
+		{Name: "a", Content: "{{> b}}"},
+		{Name: "b", Content: "{{> a}}"},
+	}
+
+	_, err := Expand(library[0], library)
+
+	var includeErr *IncludeError
+	if !errors.As(err, &includeErr) || !includeErr.Cycle {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+	if got := err.Error(); got != "include cycle: a -> b -> a" {
+		t.Fatalf("unexpected error message %q", got)
+	}
+}
+
+func TestExpandReportsMissingIncludeChain(t *testing.T) {
+	library := []Prompt{
+		{Name: "a", Content: "{{> b}}"},
+		{Name: "b", Content: "{{> missing}}"},
+	}
+
+	_, err := Expand(library[0], library)
+	if err == nil || err.Error() != `included prompt "missing" not found: a -> b -> missing` {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
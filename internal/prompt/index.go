@@ -0,0 +1,184 @@
+package prompt
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	indexFileName = "index.json"
+	indexVersion  = 2
+)
+
+// index is the on-disk cache of parsed prompt files kept in Options.CacheDir.
+// Entries are keyed by absolute path and reused as long as the file's
+// modification time and size are unchanged.
+type index struct {
+	path    string
+	mu      sync.Mutex // guards the fields below while files load concurrently
+	entries map[string]indexEntry
+	used    map[string]struct{}
+	dirty   bool
+}
+
+type indexFile struct {
+	Version int                   `json:"version"`
+	Entries map[string]indexEntry `json:"entries"`
+}
+
+type indexEntry struct {
+	Name        string     `json:"name"`
+	Path        string     `json:"path"`
+	ModTime     int64      `json:"mtime"`
+	Size        int64      `json:"size"`
+	FrontMatter string     `json:"front_matter,omitempty"` // raw YAML, decoded on use
+	Tags        []string   `json:"tags,omitempty"`
+	Variables   []Variable `json:"variables,omitempty"`
+	Content     string     `json:"content"`
+	SearchText  string     `json:"search_text"`
+}
+
+// openIndex reads the index from cacheDir. A missing, unreadable or outdated
+// index simply starts out empty.
+func openIndex(cacheDir string) *index {
+	idx := &index{
+		path:    filepath.Join(cacheDir, indexFileName),
+		entries: make(map[string]indexEntry),
+		used:    make(map[string]struct{}),
+	}
+
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		return idx
+	}
+
+	var file indexFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != indexVersion {
+		idx.dirty = true
+		return idx
+	}
+	if file.Entries != nil {
+		idx.entries = file.Entries
+	}
+	return idx
+}
+
+// load returns the prompt at path, reading and parsing the file only when the
+// cached entry is missing or stale.
+func (idx *index) load(path, absPath string, info os.FileInfo) (Prompt, error) {
+	idx.mu.Lock()
+	idx.used[absPath] = struct{}{}
+	entry, ok := idx.entries[absPath]
+	idx.mu.Unlock()
+
+	if ok && entry.ModTime == info.ModTime().UnixNano() && entry.Size == info.Size() {
+		p := entry.prompt()
+		p.Path = path
+		return p, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Prompt{}, err
+	}
+	p, err := buildPrompt(path, data)
+	if err != nil {
+		// Files with problems are not cached so that they are reported again.
+		return p, err
+	}
+	// The front matter is kept as written: a JSON round trip of the decoded
+	// values would turn dates into strings and integers into floats.
+	frontMatter, _, _ := splitFrontMatter(data)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.entries[absPath] = indexEntry{
+		Name:        p.Name,
+		Path:        absPath,
+		ModTime:     info.ModTime().UnixNano(),
+		Size:        info.Size(),
+		FrontMatter: frontMatter,
+		Tags:        p.Tags,
+		Variables:   p.Variables,
+		Content:     p.Content,
+		SearchText:  p.SearchText,
+	}
+	idx.dirty = true
+	return p, nil
+}
+
+func (e indexEntry) prompt() Prompt {
+	var frontMatter map[string]any
+	if strings.TrimSpace(e.FrontMatter) != "" {
+		// The text decoded when the entry was written, so it decodes again.
+		frontMatter, _, _ = decodeFrontMatter(e.FrontMatter)
+	}
+	return Prompt{
+		Name:        e.Name,
+		Path:        e.Path,
+		Content:     e.Content,
+		FrontMatter: frontMatter,
+		Tags:        e.Tags,
+		Variables:   e.Variables,
+		SearchText:  e.SearchText,
+	}
+}
+
+// save writes the index back when it changed. Entries below the scanned roots
+// that were not seen in this run belong to deleted files and are dropped.
+func (idx *index) save(roots []string) error {
+	for absPath := range idx.entries {
+		if _, ok := idx.used[absPath]; ok {
+			continue
+		}
+		if underAny(absPath, roots) {
+			delete(idx.entries, absPath)
+			idx.dirty = true
+		}
+	}
+
+	if !idx.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(indexFile{Version: indexVersion, Entries: idx.entries})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(idx.path), indexFileName+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), idx.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	idx.dirty = false
+	return nil
+}
+
+func underAny(path string, roots []string) bool {
+	for _, root := range roots {
+		if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadFromDirsReusesIndexedPrompts(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	path := filepath.Join(dir, "review.md")
+	writeFile(t, path, "---\ntags: [review]\n---\nOriginal content\n")
+
+	opts := Options{Extensions: []string{".md"}, CacheDir: cacheDir}
+	if _, err := LoadFromDirs([]string{dir}, opts); err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, indexFileName)); err != nil {
+		t.Fatalf("expected index to be written: %v", err)
+	}
+
+	// Rewrite the file with the same size and modification time: the index
+	// entry is still considered fresh, proving the file was not re-read.
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	writeFile(t, path, "---\ntags: [review]\n---\nModified content\n")
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	prompts, err := LoadFromDirs([]string{dir}, opts)
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].Content != "Original content\n" {
+		t.Fatalf("expected cached prompt, got %#v", prompts)
+	}
+	if prompts[0].Name != "review" || len(prompts[0].Tags) != 1 || prompts[0].SearchText != "original content" {
+		t.Fatalf("expected cached metadata, got %#v", prompts[0])
+	}
+
+	later := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	prompts, err = LoadFromDirs([]string{dir}, opts)
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	if prompts[0].Content != "Modified content\n" {
+		t.Fatalf("expected changed file to be re-read, got %q", prompts[0].Content)
+	}
+}
+
+func TestIndexKeepsFrontMatterTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "release.md"), "---\ndate: 2024-01-02\nversion: 3\nratio: 0.5\n---\nNotes\n")
+
+	opts := Options{Extensions: []string{".md"}, CacheDir: filepath.Join(t.TempDir(), "cache")}
+	cold, err := LoadFromDirs([]string{dir}, opts)
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	warm, err := LoadFromDirs([]string{dir}, opts)
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+
+	if _, ok := cold[0].FrontMatter["date"].(time.Time); !ok {
+		t.Fatalf("expected a time.Time date, got %T", cold[0].FrontMatter["date"])
+	}
+	if !reflect.DeepEqual(cold[0].FrontMatter, warm[0].FrontMatter) {
+		t.Fatalf("expected the cached front matter to match a fresh load:\n%#v\n%#v", cold[0].FrontMatter, warm[0].FrontMatter)
+	}
+}
+
+func TestIndexDropsDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := t.TempDir()
+	keep := filepath.Join(dir, "keep.md")
+	gone := filepath.Join(dir, "gone.md")
+	writeFile(t, keep, "keep\n")
+	writeFile(t, gone, "gone\n")
+
+	opts := Options{CacheDir: cacheDir}
+	if _, err := LoadFromDirs([]string{dir}, opts); err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := LoadFromDirs([]string{dir}, opts); err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+
+	idx := openIndex(cacheDir)
+	if len(idx.entries) != 1 {
+		t.Fatalf("expected 1 index entry after deletion, got %d", len(idx.entries))
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
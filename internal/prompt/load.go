@@ -0,0 +1,333 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/hzionn/prompt-manager-cli/internal/ignore"
+)
+
+// errStopped ends discovery early once parsing has failed.
+var errStopped = errors.New("discovery stopped")
+
+// candidate is a prompt file found by discovery, numbered in discovery order.
+// Problems met during discovery travel the same way so that diagnostics keep
+// the discovery order too.
+type candidate struct {
+	seq        int
+	source     int // index into the sources being loaded
+	path       string
+	absPath    string
+	info       os.FileInfo // set when the index or a size limit needs it
+	diagnostic *Diagnostic // set instead of a file to parse
+}
+
+type parsed struct {
+	candidate  candidate
+	prompt     Prompt
+	ok         bool // prompt was loaded
+	diagnostic *Diagnostic
+	err        error // fails the load in strict mode
+}
+
+// discovery is a running walk of the sources that emits candidates in order.
+type discovery struct {
+	candidates chan candidate
+	stop       chan struct{}
+	stopOnce   sync.Once
+	err        error // valid once candidates is closed
+}
+
+func (d *discovery) halt() {
+	d.stopOnce.Do(func() { close(d.stop) })
+}
+
+// startDiscovery walks the sources in a goroutine. The returned function
+// reports the walk error and must only be called after the candidates have
+// been drained.
+func startDiscovery(sources []Source, opts Options, needInfo bool) (*discovery, func() error) {
+	d := &discovery{
+		candidates: make(chan candidate),
+		stop:       make(chan struct{}),
+	}
+	go func() {
+		defer close(d.candidates)
+		d.err = discover(sources, opts, needInfo, func(c candidate) bool {
+			select {
+			case d.candidates <- c:
+				return true
+			case <-d.stop:
+				return false
+			}
+		})
+		if errors.Is(d.err, errStopped) {
+			d.err = nil
+		}
+	}()
+	return d, func() error { return d.err }
+}
+
+// discover walks each source in turn, applying ignore rules, extension and
+// size filters, and calls emit for every file to parse. Files already seen
+// through an earlier source are skipped.
+func discover(sources []Source, base Options, needInfo bool, emit func(candidate) bool) error {
+	w := walker{
+		needInfo: needInfo,
+		emit:     emit,
+		seen:     make(map[string]struct{}),
+		visited:  make(map[fileKey]struct{}),
+	}
+
+	for i, source := range sources {
+		w.source = i
+		w.opts = source.options(base)
+		w.ignored = ignore.New(w.opts.IgnorePatterns)
+		err := w.walk(source.Path, source.Path, "")
+
+		if errors.Is(err, errStopped) {
+			return err
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// walker holds the state of a discovery walk across sources.
+type walker struct {
+	source   int
+	opts     Options
+	ignored  *ignore.Matcher
+	needInfo bool
+	emit     func(candidate) bool
+	seq      int
+	seen     map[string]struct{}  // resolved paths of the files emitted so far
+	visited  map[fileKey]struct{} // directories entered while following links
+}
+
+// walk discovers the files under dir, reporting them below shown, the path
+// they are reached through. prefix is the slash-separated path of dir
+// relative to the source root, used to match ignore rules.
+func (w *walker) walk(dir, shown, prefix string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, os.ErrNotExist) {
+				// Missing roots are fine, and files may vanish while walking.
+				return nil
+			}
+			return w.report(path, walkErr)
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(shown, rel)
+		rel = joinRel(prefix, filepath.ToSlash(rel))
+
+		if d.IsDir() {
+			if rel != "." && w.ignored.Match(rel, true) {
+				return filepath.SkipDir
+			}
+			if w.opts.FollowSymlinks && !w.enter(path) {
+				return filepath.SkipDir
+			}
+			// Ignore files apply to the directory they are found in and below.
+			for _, name := range ignore.FileNames {
+				if err := w.ignored.AddFile(rel, filepath.Join(path, name)); err != nil {
+					if err := w.report(filepath.Join(path, name), err); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+
+		if d.Type()&os.ModeSymlink != 0 {
+			info, err := os.Stat(path)
+			if err != nil {
+				// A dangling link is not a prompt.
+				return nil
+			}
+			if info.IsDir() {
+				if !w.opts.FollowSymlinks || (rel != "." && w.ignored.Match(rel, true)) {
+					return nil
+				}
+				target, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					return nil
+				}
+				return w.walk(target, path, rel)
+			}
+		}
+
+		if isIgnoreFile(d.Name()) || w.ignored.Match(rel, false) {
+			return nil
+		}
+
+		if len(w.opts.Extensions) > 0 && !hasAllowedExtension(path, w.opts.Extensions) {
+			return nil
+		}
+
+		var info os.FileInfo
+		if (w.opts.MaxFileSize > 0 && d.Type().IsRegular()) || w.needInfo {
+			var err error
+			if info, err = os.Stat(path); err != nil {
+				return w.report(path, err)
+			}
+			if w.opts.MaxFileSize > 0 && d.Type().IsRegular() && info.Size() > w.opts.MaxFileSize {
+				return w.send(candidate{path: path, diagnostic: &Diagnostic{
+					Kind:    DiagnosticSkipped,
+					Path:    path,
+					Message: fmt.Sprintf("%d KB is over the %d KB size limit", (info.Size()+1023)/1024, w.opts.MaxFileSize/1024),
+				}})
+			}
+		}
+
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if w.opts.FollowSymlinks {
+			// Dedupe on the real path so a file reached through a link and
+			// directly is loaded once.
+			if absPath, err = filepath.EvalSymlinks(absPath); err != nil {
+				return nil
+			}
+		}
+
+		if _, ok := w.seen[absPath]; ok {
+			return nil
+		}
+		w.seen[absPath] = struct{}{}
+
+		return w.send(candidate{path: path, absPath: absPath, info: info})
+	})
+}
+
+// send numbers c and passes it on, returning errStopped once the load has
+// failed.
+func (w *walker) send(c candidate) error {
+	c.seq = w.seq
+	c.source = w.source
+	if !w.emit(c) {
+		return errStopped
+	}
+	w.seq++
+	return nil
+}
+
+// report records err for path as a diagnostic, or returns it to end the walk
+// in strict mode.
+func (w *walker) report(path string, err error) error {
+	if w.opts.Strict {
+		return err
+	}
+	diagnostic := diagnose(path, err)
+	return w.send(candidate{path: path, diagnostic: &diagnostic})
+}
+
+// enter records the directory at path as visited and reports whether it was
+// new. A directory seen before was reached through a link, either a loop back
+// to an ancestor or a second way into a tree that is already loaded.
+func (w *walker) enter(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	key, err := keyOf(path, info)
+	if err != nil {
+		return false
+	}
+	if _, ok := w.visited[key]; ok {
+		return false
+	}
+	w.visited[key] = struct{}{}
+	return true
+}
+
+func joinRel(prefix, rel string) string {
+	switch {
+	case prefix == "" || prefix == ".":
+		return rel
+	case rel == ".":
+		return prefix
+	default:
+		return prefix + "/" + rel
+	}
+}
+
+// parseCandidates reads the discovered files with a pool of workers and
+// returns them in discovery order. A file that fails to load becomes a
+// diagnostic, or in strict mode stops further files from being handed out,
+// and the error of the earliest failed file is returned.
+func parseCandidates(d *discovery, idx *index, workers int, strict bool) ([]parsed, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	out := make(chan parsed)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range d.candidates {
+				out <- parseCandidate(c, idx, strict)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	var results []parsed
+	var firstErr *parsed
+	for r := range out {
+		for len(results) <= r.candidate.seq {
+			results = append(results, parsed{})
+		}
+		results[r.candidate.seq] = r
+		if r.err != nil {
+			d.halt()
+			if firstErr == nil || r.candidate.seq < firstErr.candidate.seq {
+				failed := r
+				firstErr = &failed
+			}
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr.err
+	}
+	return results, nil
+}
+
+func parseCandidate(c candidate, idx *index, strict bool) parsed {
+	if c.diagnostic != nil {
+		return parsed{candidate: c, diagnostic: c.diagnostic}
+	}
+
+	var p Prompt
+	var err error
+	if idx != nil {
+		p, err = idx.load(c.path, c.absPath, c.info)
+	} else {
+		p, err = readPrompt(c.path)
+	}
+	if err == nil {
+		return parsed{candidate: c, prompt: p, ok: true}
+	}
+	if strict {
+		return parsed{candidate: c, err: err}
+	}
+
+	diagnostic := diagnose(c.path, err)
+	// Front matter problems still leave a usable prompt.
+	return parsed{candidate: c, prompt: p, ok: diagnostic.Kind == DiagnosticFrontMatter, diagnostic: &diagnostic}
+}
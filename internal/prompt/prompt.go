@@ -11,97 +11,183 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/hzionn/prompt-manager-cli/internal/ignore"
 )
 
 // Prompt represents a prompt file and its derived metadata.
 type Prompt struct {
 	Name        string
+	ID          string // root alias and relative path, e.g. "work:code/review"
 	Path        string
 	Content     string
 	FrontMatter map[string]any
 	Tags        []string
+	Variables   []Variable
+	SearchText  string  // normalized content used for full-text matching
+	Source      string  // name of the source the prompt was loaded from
+	ReadOnly    bool    // the source must not be modified
+	Weight      float64 // ranking weight of the source; 0 means 1
 }
 
 // Options configure prompt discovery.
 type Options struct {
+	Extensions     []string
+	IgnorePatterns []string
+	MaxFileSize    int64  // bytes
+	CacheDir       string // directory for the on-disk index; empty disables it
+	Workers        int    // files parsed concurrently; 0 uses GOMAXPROCS
+	FollowSymlinks bool   // descend into symlinked directories
+	Strict         bool   // fail on the first problem instead of reporting it
+}
+
+var normalizerReplacer = strings.NewReplacer(
+	"_", " ",
+	"-", " ",
+	"/", " ",
+	".", " ",
+	",", " ",
+	"\n", " ",
+	"\r", " ",
+	"\t", " ",
+)
+
+// Source is a named directory of prompts. Its extensions, ignore patterns and
+// maximum file size replace those of the Options passed to LoadFromSources
+// when set.
+type Source struct {
+	Name           string // ID prefix; derived from the directory when empty
+	Path           string
 	Extensions     []string
 	IgnorePatterns []string
 	MaxFileSize    int64 // bytes
+	ReadOnly       bool
+	Weight         float64
 }
 
 // LoadFromDirs discovers prompt files under the provided directories using the supplied options.
 func LoadFromDirs(dirs []string, opts Options) ([]Prompt, error) {
-	var prompts []Prompt
-	seen := make(map[string]struct{})
-
+	sources := make([]Source, 0, len(dirs))
 	for _, dir := range dirs {
-		err := filepath.WalkDir(dir, func(path string, d os.DirEntry, walkErr error) error {
-			if walkErr != nil {
-				return walkErr
-			}
-
-			if d.IsDir() {
-				if shouldIgnore(path, opts.IgnorePatterns) {
-					return filepath.SkipDir
-				}
-				return nil
-			}
+		sources = append(sources, Source{Path: dir})
+	}
+	return LoadFromSources(sources, opts)
+}
 
-			if shouldIgnore(path, opts.IgnorePatterns) {
-				return nil
-			}
+// LoadFromSources discovers prompt files in each source, applying the
+// source's own discovery settings on top of opts. See LoadWithDiagnostics.
+func LoadFromSources(sources []Source, opts Options) ([]Prompt, error) {
+	prompts, _, err := LoadWithDiagnostics(sources, opts)
+	return prompts, err
+}
 
-			if len(opts.Extensions) > 0 && !hasAllowedExtension(path, opts.Extensions) {
-				return nil
-			}
+// LoadWithDiagnostics works like LoadFromSources and also returns the problems
+// it worked around: files skipped for their size, unusable front matter and
+// files or directories that could not be read. With opts.Strict any of these
+// but a skipped file is returned as the error instead.
+//
+// A file reachable from more than one source is loaded once, from the first.
+// Files are parsed by opts.Workers goroutines while discovery continues; the
+// result keeps the discovery order, and the error returned is the one for the
+// earliest file.
+func LoadWithDiagnostics(sources []Source, opts Options) ([]Prompt, []Diagnostic, error) {
+	var idx *index
+	if opts.CacheDir != "" {
+		idx = openIndex(opts.CacheDir)
+	}
 
-			if opts.MaxFileSize > 0 && d.Type().IsRegular() {
-				info, err := d.Info()
-				if err != nil {
-					return err
-				}
-				if info.Size() > opts.MaxFileSize {
-					return nil
-				}
-			}
+	names := sourceNames(sources)
+	found, walkErr := startDiscovery(sources, opts, idx != nil)
+	results, err := parseCandidates(found, idx, opts.Workers, opts.Strict)
+	if err == nil {
+		err = walkErr()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
 
-			absPath, err := filepath.Abs(path)
-			if err != nil {
-				return err
-			}
+	prompts := make([]Prompt, 0, len(results))
+	var diagnostics []Diagnostic
+	for _, r := range results {
+		if r.diagnostic != nil {
+			diagnostics = append(diagnostics, *r.diagnostic)
+		}
+		if !r.ok {
+			continue
+		}
+		source := sources[r.candidate.source]
+		p := r.prompt
+		p.ID = promptID(names[r.candidate.source], source.Path, r.candidate.path)
+		p.Source = names[r.candidate.source]
+		p.ReadOnly = source.ReadOnly
+		p.Weight = source.Weight
+		prompts = append(prompts, p)
+	}
 
-			if _, ok := seen[absPath]; ok {
-				return nil
-			}
+	disambiguateIDs(prompts)
 
-			fileBytes, err := os.ReadFile(path)
-			if err != nil {
-				return err
+	if idx != nil {
+		roots := make([]string, 0, len(sources))
+		for _, source := range sources {
+			if abs, err := filepath.Abs(source.Path); err == nil {
+				roots = append(roots, abs)
 			}
+		}
+		// The index is only a cache; failing to persist it must not fail the load.
+		_ = idx.save(roots)
+	}
 
-			prompt, err := buildPrompt(path, fileBytes)
-			if err != nil {
-				return err
-			}
+	return prompts, diagnostics, nil
+}
 
-			prompts = append(prompts, prompt)
-			seen[absPath] = struct{}{}
+// NormalizeText lowercases value and folds separators into single spaces so
+// that names, tags and content can be compared loosely.
+func NormalizeText(value string) string {
+	if value == "" {
+		return ""
+	}
+	clean := normalizerReplacer.Replace(strings.ToLower(value))
+	return strings.Join(strings.Fields(clean), " ")
+}
 
-			return nil
-		})
+// Reload reads the prompt's file again, keeping the identity it was loaded with.
+func Reload(p Prompt) (Prompt, error) {
+	fresh, err := readPrompt(p.Path)
+	if err != nil {
+		return Prompt{}, err
+	}
+	fresh.Name = p.Name
+	fresh.ID = p.ID
+	fresh.Source = p.Source
+	fresh.ReadOnly = p.ReadOnly
+	fresh.Weight = p.Weight
+	return fresh, nil
+}
 
-		if err != nil && !errors.Is(err, os.ErrNotExist) {
-			return nil, err
-		}
+func (s Source) options(opts Options) Options {
+	if len(s.Extensions) > 0 {
+		opts.Extensions = s.Extensions
+	}
+	if len(s.IgnorePatterns) > 0 {
+		opts.IgnorePatterns = s.IgnorePatterns
+	}
+	if s.MaxFileSize > 0 {
+		opts.MaxFileSize = s.MaxFileSize
 	}
+	return opts
+}
 
-	return prompts, nil
+func readPrompt(path string) (Prompt, error) {
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		return Prompt{}, err
+	}
+	return buildPrompt(path, fileBytes)
 }
 
-func shouldIgnore(path string, patterns []string) bool {
-	for _, pattern := range patterns {
-		matched, err := filepath.Match(pattern, filepath.Base(path))
-		if err == nil && matched {
+func isIgnoreFile(name string) bool {
+	for _, ignoreName := range ignore.FileNames {
+		if name == ignoreName {
 			return true
 		}
 	}
@@ -118,38 +204,85 @@ func hasAllowedExtension(path string, extensions []string) bool {
 	return false
 }
 
+// buildPrompt parses a prompt file. Unusable front matter is reported as a
+// *FrontMatterError together with a prompt that is still valid: the whole
+// file as content when the YAML does not parse, no variables when they are
+// invalid.
 func buildPrompt(path string, data []byte) (Prompt, error) {
-	frontMatter, content := parseFrontMatter(data)
+	frontMatter, doc, content, err := parseFrontMatter(data)
+	var frontMatterErr error
+	if err != nil {
+		frontMatterErr = newFrontMatterError(path, err, 0)
+	}
 	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
 
 	tags := extractTags(frontMatter)
 
+	var variables []Variable
+	if doc != nil {
+		variables, err = parseVariables(doc)
+		if err != nil {
+			line := 0
+			if node := mappingValue(doc, "variables"); node != nil {
+				line = node.Line
+			}
+			frontMatterErr = newFrontMatterError(path, err, line)
+			variables = nil
+		}
+	}
+
 	return Prompt{
 		Name:        name,
 		Path:        path,
 		Content:     content,
 		FrontMatter: frontMatter,
 		Tags:        tags,
-	}, nil
+		Variables:   variables,
+		SearchText:  NormalizeText(content),
+	}, frontMatterErr
 }
 
-func parseFrontMatter(data []byte) (map[string]any, string) {
+// parseFrontMatter splits data into its YAML front matter and content. The
+// decoded document node is returned alongside the map so callers can read
+// fields whose declaration order matters. Front matter that does not parse is
+// returned as an error, with the whole of data as the content.
+func parseFrontMatter(data []byte) (map[string]any, *yaml.Node, string, error) {
+	raw, content, ok := splitFrontMatter(data)
+	if !ok {
+		return nil, nil, string(data), nil
+	}
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil, content, nil
+	}
+
+	front, doc, err := decodeFrontMatter(raw)
+	if err != nil {
+		return nil, nil, string(data), err
+	}
+	return front, doc, content, nil
+}
+
+// splitFrontMatter returns the raw YAML between the --- delimiters that open
+// data and the content after them. ok is false when data has no complete
+// front matter block.
+func splitFrontMatter(data []byte) (raw, content string, ok bool) {
 	reader := bufio.NewReader(bytes.NewReader(data))
 
 	firstLine, err := reader.ReadString('\n')
 	if err != nil && !errors.Is(err, io.EOF) {
-		return nil, string(data)
+		return "", "", false
 	}
 
 	if strings.TrimSpace(firstLine) != "---" {
-		return nil, string(data)
+		return "", "", false
 	}
 
 	var buf strings.Builder
 	for {
 		line, err := reader.ReadString('\n')
 		if errors.Is(err, io.EOF) {
-			return nil, string(data)
+			// Without a closing delimiter the dashes are taken as content.
+			return "", "", false
 		}
 
 		if strings.TrimSpace(line) == "---" {
@@ -159,23 +292,21 @@ func parseFrontMatter(data []byte) (map[string]any, string) {
 		buf.WriteString(line)
 	}
 
-	raw := buf.String()
-	if strings.TrimSpace(raw) == "" {
-		rest, _ := io.ReadAll(reader)
-		content := strings.TrimLeft(string(rest), "\r\n")
-		return nil, content
-	}
+	rest, _ := io.ReadAll(reader)
+	return buf.String(), strings.TrimLeft(string(rest), "\r\n"), true
+}
 
+// decodeFrontMatter decodes the raw YAML of a front matter block.
+func decodeFrontMatter(raw string) (map[string]any, *yaml.Node, error) {
+	var doc yaml.Node
 	var front map[string]any
-	if err := yaml.Unmarshal([]byte(raw), &front); err != nil {
-		// If parsing fails, fall back to treating the data as raw content.
-		return nil, string(data)
+	if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+		return nil, nil, err
 	}
-
-	rest, _ := io.ReadAll(reader)
-	content := strings.TrimLeft(string(rest), "\r\n")
-
-	return normalizeFrontMatter(front), content
+	if err := doc.Decode(&front); err != nil {
+		return nil, nil, err
+	}
+	return normalizeFrontMatter(front), &doc, nil
 }
 
 func normalizeFrontMatter(input map[string]any) map[string]any {
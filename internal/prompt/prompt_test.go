@@ -1,7 +1,10 @@
 package prompt
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -93,3 +96,219 @@ func TestLoadFromDirsRespectsExtensions(t *testing.T) {
 func contentHasFrontMatter(content string) bool {
 	return len(content) > 0 && content[0] == '-'
 }
+
+func TestReloadRereadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	writeFile(t, path, "before\n")
+
+	p, err := readPrompt(path)
+	if err != nil {
+		t.Fatalf("readPrompt() error = %v", err)
+	}
+
+	writeFile(t, path, "---\ntags: [fresh]\n---\nafter\n")
+	reloaded, err := Reload(p)
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if reloaded.Name != "note" || reloaded.Content != "after\n" || len(reloaded.Tags) != 1 {
+		t.Fatalf("unexpected reloaded prompt %#v", reloaded)
+	}
+}
+
+func TestLoadFromDirsAppliesIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"templates/drafts", ".obsidian", "work/archive", "work/keep"} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+	}
+	for _, name := range []string{
+		"review.md",
+		"templates/base.md",
+		"templates/drafts/idea.md",
+		".obsidian/workspace.md",
+		"work/plan.md",
+		"work/scratch.md",
+		"work/archive/old.md",
+		"work/keep/old.md",
+	} {
+		writeFile(t, filepath.Join(root, filepath.FromSlash(name)), "# "+name)
+	}
+	writeFile(t, filepath.Join(root, ".gitignore"), "scratch.md\n")
+	writeFile(t, filepath.Join(root, "work", ".pmignore"), "/archive/\n*.md\n!plan.md\n!keep/\n!keep/*.md\n")
+
+	opts := Options{IgnorePatterns: []string{"templates/drafts/**", ".obsidian/"}}
+	prompts, err := LoadFromDirs([]string{root}, opts)
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+
+	var ids []string
+	for _, p := range prompts {
+		ids = append(ids, strings.TrimPrefix(p.ID, filepath.Base(root)+":"))
+	}
+	want := "review templates/base work/keep/old work/plan"
+	if strings.Join(ids, " ") != want {
+		t.Fatalf("expected %q, got %q", want, strings.Join(ids, " "))
+	}
+}
+
+func TestLoadFromSourcesKeepsOrderAcrossWorkers(t *testing.T) {
+	roots := []string{t.TempDir(), t.TempDir()}
+	for i, root := range roots {
+		writeTree(t, root, 50+i*25)
+	}
+	sources := []Source{{Name: "a", Path: roots[0]}, {Name: "b", Path: roots[1]}}
+
+	serial, err := LoadFromSources(sources, Options{Workers: 1})
+	if err != nil {
+		t.Fatalf("LoadFromSources(workers=1) error = %v", err)
+	}
+	if len(serial) != 125 {
+		t.Fatalf("expected 125 prompts, got %d", len(serial))
+	}
+
+	for range 5 {
+		parallel, err := LoadFromSources(sources, Options{Workers: 8})
+		if err != nil {
+			t.Fatalf("LoadFromSources(workers=8) error = %v", err)
+		}
+		if len(parallel) != len(serial) {
+			t.Fatalf("expected %d prompts, got %d", len(serial), len(parallel))
+		}
+		for i := range serial {
+			if parallel[i].ID != serial[i].ID {
+				t.Fatalf("prompt %d: expected %s, got %s", i, serial[i].ID, parallel[i].ID)
+			}
+		}
+	}
+}
+
+func TestStrictLoadReturnsFirstError(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, 400)
+	badVariables := "---\nvariables: 3\n---\nbody\n"
+	writeFile(t, filepath.Join(root, "dir-01", "a-bad.md"), badVariables)
+	writeFile(t, filepath.Join(root, "dir-03", "z-bad.md"), badVariables)
+
+	for _, workers := range []int{1, 4, 16} {
+		_, err := LoadFromSources([]Source{{Path: root}}, Options{Workers: workers, Strict: true})
+		if err == nil {
+			t.Fatalf("workers=%d: expected an error", workers)
+		}
+		if !strings.Contains(err.Error(), filepath.Join("dir-01", "a-bad.md")) {
+			t.Fatalf("workers=%d: expected the error for dir-01/a-bad.md, got %v", workers, err)
+		}
+	}
+}
+
+// BenchmarkLoadFromSources loads a generated 10k-file tree with a single
+// worker and with the default pool to show the effect of parsing in parallel.
+func BenchmarkLoadFromSources(b *testing.B) {
+	root := b.TempDir()
+	writeTree(b, root, 10000)
+	sources := []Source{{Path: root}}
+
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{
+		{"workers=1", 1},
+		{"workers=4", 4},
+		{"workers=default", 0},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			opts := Options{Extensions: []string{".md"}, Workers: bench.workers}
+			for b.Loop() {
+				prompts, err := LoadFromSources(sources, opts)
+				if err != nil {
+					b.Fatalf("LoadFromSources() error = %v", err)
+				}
+				if len(prompts) != 10000 {
+					b.Fatalf("expected 10000 prompts, got %d", len(prompts))
+				}
+			}
+		})
+	}
+}
+
+// writeTree writes n prompts with front matter under root, spread over
+// directories of 100 files each.
+func writeTree(tb testing.TB, root string, n int) {
+	tb.Helper()
+	for i := range n {
+		dir := filepath.Join(root, fmt.Sprintf("dir-%02d", i/100))
+		if i%100 == 0 {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				tb.Fatalf("MkdirAll() error = %v", err)
+			}
+		}
+		content := fmt.Sprintf("---\ntags: [generated, batch-%d]\nvariables:\n  topic: {default: testing}\n---\nPrompt %d about {{topic}}.\n", i%7, i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("prompt-%05d.md", i)), []byte(content), 0o600); err != nil {
+			tb.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+}
+
+func TestLoadFromDirsFollowsSymlinkedDirectories(t *testing.T) {
+	shared := t.TempDir()
+	writeFile(t, filepath.Join(shared, "common.md"), "# shared")
+	// A link back to the shared folder itself must not loop.
+	symlink(t, shared, filepath.Join(shared, "loop"))
+
+	vault := t.TempDir()
+	writeFile(t, filepath.Join(vault, "own.md"), "# own")
+	symlink(t, shared, filepath.Join(vault, "shared"))
+	// A second link into the same folder loads its prompts once.
+	symlink(t, shared, filepath.Join(vault, "again"))
+
+	prompts, err := LoadFromDirs([]string{vault}, Options{})
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	if len(prompts) != 1 {
+		t.Fatalf("expected symlinked directories to be skipped by default, got %d prompts", len(prompts))
+	}
+
+	prompts, err = LoadFromDirs([]string{vault}, Options{FollowSymlinks: true})
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	var ids []string
+	for _, p := range prompts {
+		ids = append(ids, strings.TrimPrefix(p.ID, filepath.Base(vault)+":"))
+	}
+	if strings.Join(ids, " ") != "again/common own" {
+		t.Fatalf("expected again/common and own, got %v", ids)
+	}
+	if want := filepath.Join(vault, "again", "common.md"); prompts[0].Path != want {
+		t.Fatalf("expected the path through the link %s, got %s", want, prompts[0].Path)
+	}
+}
+
+func TestLoadFromDirsDedupesFilesReachedThroughLinks(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.md"), "# a")
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	symlink(t, filepath.Join(root, "a.md"), filepath.Join(root, "sub", "b.md"))
+	symlink(t, root, filepath.Join(root, "sub", "up"))
+
+	prompts, err := LoadFromDirs([]string{root, filepath.Join(root, "sub")}, Options{FollowSymlinks: true})
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].Name != "a" {
+		t.Fatalf("expected a.md once, got %d prompts", len(prompts))
+	}
+}
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+}
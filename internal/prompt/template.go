@@ -0,0 +1,125 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// RenderOptions configure how prompt templates are filled in.
+type RenderOptions struct {
+	Values map[string]string
+	// Library resolves {{> name}} includes.
+	Library []Prompt
+	// Partial leaves unfilled placeholders in place instead of failing, which
+	// is useful for previews while values are still being entered.
+	Partial bool
+}
+
+// MissingValuesError reports placeholders that were left unfilled while rendering a prompt.
+type MissingValuesError struct {
+	Prompt string
+	Names  []string
+}
+
+func (e *MissingValuesError) Error() string {
+	return fmt.Sprintf("prompt %q has unfilled placeholders: %s", e.Prompt, strings.Join(e.Names, ", "))
+}
+
+// InvalidValueError reports a value that does not satisfy its variable declaration.
+type InvalidValueError struct {
+	Prompt string
+	Name   string
+	Err    error
+}
+
+func (e *InvalidValueError) Error() string {
+	return fmt.Sprintf("prompt %q: variable %q: %v", e.Prompt, e.Name, e.Err)
+}
+
+func (e *InvalidValueError) Unwrap() error { return e.Err }
+
+// Placeholders returns the distinct placeholder names referenced by content in order of first use.
+func Placeholders(content string) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, match := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		name := match[1]
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Render expands the includes in the prompt content and fills its {{name}}
+// placeholders. Supplied values take precedence over the defaults declared in
+// front matter; declared variables that are neither required nor defaulted
+// render as empty strings.
+func Render(p Prompt, opts RenderOptions) (string, error) {
+	p, err := Expand(p, opts.Library)
+	if err != nil {
+		return "", err
+	}
+
+	values, err := resolveValues(p, opts.Values, opts.Partial)
+	if err != nil {
+		return "", err
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(p.Content, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	}), nil
+}
+
+func resolveValues(p Prompt, supplied map[string]string, partial bool) (map[string]string, error) {
+	values := make(map[string]string)
+	var missing []string
+
+	for _, v := range p.TemplateVariables() {
+		value, ok := supplied[v.Name]
+		if ok && v.Required && strings.TrimSpace(value) == "" {
+			ok = false
+		}
+		if !ok {
+			switch {
+			case v.Default != "":
+				value = v.Default
+			case v.Required || !p.declares(v.Name):
+				missing = append(missing, v.Name)
+				continue
+			}
+		}
+
+		normalized, err := v.Validate(value)
+		if ok && err != nil && !partial {
+			return nil, &InvalidValueError{Prompt: p.Name, Name: v.Name, Err: err}
+		}
+		if err == nil {
+			value = normalized
+		}
+		values[v.Name] = value
+	}
+
+	if len(missing) > 0 && !partial {
+		return nil, &MissingValuesError{Prompt: p.Name, Names: missing}
+	}
+	return values, nil
+}
+
+func (p Prompt) declares(name string) bool {
+	for _, v := range p.Variables {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
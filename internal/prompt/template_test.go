@@ -0,0 +1,92 @@
+package prompt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPlaceholdersReturnsNamesInOrder(t *testing.T) {
+	content := "Review this {{language}} code for {{ audience }}. Keep {{language}} idioms."
+
+	got := Placeholders(content)
+	want := []string{"language", "audience"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Placeholders() = %v, want %v", got, want)
+	}
+}
+
+func TestRenderFillsPlaceholders(t *testing.T) {
+	p := Prompt{Name: "review", Content: "Review this {{language}} code. Use {{ language }} idioms."}
+
+	got, err := Render(p, RenderOptions{Values: map[string]string{"language": "Go"}})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := "Review this Go code. Use Go idioms."
+	if got != want {
+		t.Fatalf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderReportsUnfilledPlaceholders(t *testing.T) {
+	p := Prompt{Name: "review", Content: "{{language}} for {{audience}} and {{tone}}"}
+
+	_, err := Render(p, RenderOptions{Values: map[string]string{"audience": "juniors"}})
+
+	var missing *MissingValuesError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected MissingValuesError, got %v", err)
+	}
+	if !reflect.DeepEqual(missing.Names, []string{"language", "tone"}) {
+		t.Fatalf("unexpected missing names %v", missing.Names)
+	}
+}
+
+func TestRenderUsesDeclaredVariables(t *testing.T) {
+	p := Prompt{
+		Name:    "review",
+		Content: "Review {{language}} code{{note}}.",
+		Variables: []Variable{
+			{Name: "language", Type: VariableEnum, Default: "Go", Options: []string{"Go", "Rust"}},
+			{Name: "note", Type: VariableString},
+		},
+	}
+
+	got, err := Render(p, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if got != "Review Go code." {
+		t.Fatalf("Render() = %q", got)
+	}
+
+	got, err = Render(p, RenderOptions{Values: map[string]string{"language": "rust"}})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if got != "Review Rust code." {
+		t.Fatalf("Render() = %q", got)
+	}
+
+	_, err = Render(p, RenderOptions{Values: map[string]string{"language": "COBOL"}})
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) || invalid.Name != "language" {
+		t.Fatalf("expected InvalidValueError for language, got %v", err)
+	}
+}
+
+func TestRenderRequiresRequiredVariables(t *testing.T) {
+	p := Prompt{
+		Name:      "estimate",
+		Content:   "Estimate {{points}} points.",
+		Variables: []Variable{{Name: "points", Type: VariableNumber, Required: true}},
+	}
+
+	_, err := Render(p, RenderOptions{Values: map[string]string{"points": " "}})
+	var missing *MissingValuesError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected MissingValuesError, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package prompt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VariableType describes the kind of value a template variable accepts.
+type VariableType string
+
+const (
+	VariableString    VariableType = "string"
+	VariableEnum      VariableType = "enum"
+	VariableNumber    VariableType = "number"
+	VariableMultiline VariableType = "multiline"
+)
+
+// Variable is a template variable declared in a prompt's front matter.
+type Variable struct {
+	Name        string       `json:"name"`
+	Type        VariableType `json:"type"`
+	Default     string       `json:"default,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Required    bool         `json:"required,omitempty"`
+	Options     []string     `json:"options,omitempty"` // allowed values for enum variables
+}
+
+type variableSpec struct {
+	Name        string   `yaml:"name"`
+	Type        string   `yaml:"type"`
+	Default     string   `yaml:"default"`
+	Description string   `yaml:"description"`
+	Required    bool     `yaml:"required"`
+	Options     []string `yaml:"options"`
+}
+
+// parseVariables reads the variables block from a decoded front matter document.
+// Variables may be declared as a mapping keyed by name or as a list of entries
+// with a name field; either way the declaration order is preserved.
+func parseVariables(doc *yaml.Node) ([]Variable, error) {
+	node := mappingValue(doc, "variables")
+	if node == nil {
+		return nil, nil
+	}
+
+	var vars []Variable
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name := strings.TrimSpace(node.Content[i].Value)
+			v, err := decodeVariable(name, node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			vars = append(vars, v)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			v, err := decodeVariable("", item)
+			if err != nil {
+				return nil, err
+			}
+			vars = append(vars, v)
+		}
+	default:
+		return nil, fmt.Errorf("line %d: variables must be a mapping or a list", node.Line)
+	}
+
+	seen := make(map[string]struct{}, len(vars))
+	for _, v := range vars {
+		if _, ok := seen[v.Name]; ok {
+			return nil, fmt.Errorf("variable %q declared more than once", v.Name)
+		}
+		seen[v.Name] = struct{}{}
+	}
+	return vars, nil
+}
+
+func decodeVariable(name string, node *yaml.Node) (Variable, error) {
+	var spec variableSpec
+	if node.Kind == yaml.ScalarNode {
+		// Shorthand form: `language: Go` declares a string variable with a default.
+		spec.Default = node.Value
+	} else if err := node.Decode(&spec); err != nil {
+		return Variable{}, fmt.Errorf("line %d: %w", node.Line, err)
+	}
+
+	if name == "" {
+		name = strings.TrimSpace(spec.Name)
+	}
+	if name == "" {
+		return Variable{}, fmt.Errorf("line %d: variable is missing a name", node.Line)
+	}
+
+	v := Variable{
+		Name:        name,
+		Type:        VariableType(strings.ToLower(strings.TrimSpace(spec.Type))),
+		Default:     spec.Default,
+		Description: strings.TrimSpace(spec.Description),
+		Required:    spec.Required,
+		Options:     cleanSlice(spec.Options),
+	}
+	if v.Type == "" {
+		v.Type = VariableString
+		if len(v.Options) > 0 {
+			v.Type = VariableEnum
+		}
+	}
+
+	switch v.Type {
+	case VariableString, VariableMultiline, VariableNumber:
+	case VariableEnum:
+		if len(v.Options) == 0 {
+			return Variable{}, fmt.Errorf("line %d: enum variable %q has no options", node.Line, name)
+		}
+	default:
+		return Variable{}, fmt.Errorf("line %d: variable %q has unknown type %q", node.Line, name, spec.Type)
+	}
+
+	if v.Default != "" {
+		normalized, err := v.Validate(v.Default)
+		if err != nil {
+			return Variable{}, fmt.Errorf("line %d: default for %q: %w", node.Line, name, err)
+		}
+		v.Default = normalized
+	}
+	return v, nil
+}
+
+// Validate checks value against the variable type and returns its canonical form.
+func (v Variable) Validate(value string) (string, error) {
+	switch v.Type {
+	case VariableNumber:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return "", fmt.Errorf("%q is not a number", value)
+		}
+		return strings.TrimSpace(value), nil
+	case VariableEnum:
+		for _, option := range v.Options {
+			if strings.EqualFold(option, strings.TrimSpace(value)) {
+				return option, nil
+			}
+		}
+		return "", fmt.Errorf("%q is not one of %s", value, strings.Join(v.Options, ", "))
+	case VariableString:
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("value must be a single line")
+		}
+	}
+	return value, nil
+}
+
+// TemplateVariables lists every variable the prompt needs to render: the
+// declared variables first, followed by undeclared placeholders as strings.
+func (p Prompt) TemplateVariables() []Variable {
+	vars := append([]Variable(nil), p.Variables...)
+	declared := make(map[string]struct{}, len(vars))
+	for _, v := range vars {
+		declared[v.Name] = struct{}{}
+	}
+	for _, name := range Placeholders(p.Content) {
+		if _, ok := declared[name]; ok {
+			continue
+		}
+		vars = append(vars, Variable{Name: name, Type: VariableString})
+	}
+	return vars
+}
+
+func mappingValue(doc *yaml.Node, key string) *yaml.Node {
+	node := doc
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.TrimSpace(node.Content[i].Value) == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildPromptParsesVariableDeclarations(t *testing.T) {
+	data := []byte(`---
+title: Review
+variables:
+  language:
+    type: enum
+    options: [Go, Rust]
+    default: go
+    description: Language under review
+  focus: error handling
+  lines:
+    type: number
+    required: true
+  context:
+    type: multiline
+---
+Review {{language}} code.
+`)
+
+	p, err := buildPrompt("review.md", data)
+	if err != nil {
+		t.Fatalf("buildPrompt() error = %v", err)
+	}
+
+	want := []Variable{
+		{Name: "language", Type: VariableEnum, Default: "Go", Description: "Language under review", Options: []string{"Go", "Rust"}},
+		{Name: "focus", Type: VariableString, Default: "error handling"},
+		{Name: "lines", Type: VariableNumber, Required: true},
+		{Name: "context", Type: VariableMultiline},
+	}
+	if !reflect.DeepEqual(p.Variables, want) {
+		t.Fatalf("Variables = %#v, want %#v", p.Variables, want)
+	}
+}
+
+func TestBuildPromptParsesVariableList(t *testing.T) {
+	data := []byte("---\nvariables:\n  - name: tone\n    default: friendly\n  - name: audience\n---\nBody\n")
+
+	p, err := buildPrompt("list.md", data)
+	if err != nil {
+		t.Fatalf("buildPrompt() error = %v", err)
+	}
+
+	if len(p.Variables) != 2 || p.Variables[0].Name != "tone" || p.Variables[1].Name != "audience" {
+		t.Fatalf("unexpected variables %#v", p.Variables)
+	}
+}
+
+func TestBuildPromptRejectsInvalidVariables(t *testing.T) {
+	cases := map[string]string{
+		"unknown type":  "variables:\n  a:\n    type: date\n",
+		"enum options":  "variables:\n  a:\n    type: enum\n",
+		"bad default":   "variables:\n  a:\n    type: number\n    default: many\n",
+		"missing name":  "variables:\n  - default: x\n",
+		"duplicate var": "variables:\n  - name: a\n  - name: a\n",
+	}
+
+	for name, front := range cases {
+		t.Run(name, func(t *testing.T) {
+			data := []byte("---\n" + front + "---\nBody\n")
+			if _, err := buildPrompt("bad.md", data); err == nil || !strings.Contains(err.Error(), "bad.md") {
+				t.Fatalf("expected error mentioning the file, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTemplateVariablesAppendsUndeclaredPlaceholders(t *testing.T) {
+	p := Prompt{
+		Content:   "{{topic}} for {{audience}}",
+		Variables: []Variable{{Name: "audience", Type: VariableString}},
+	}
+
+	var names []string
+	for _, v := range p.TemplateVariables() {
+		names = append(names, v.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"audience", "topic"}) {
+		t.Fatalf("TemplateVariables() names = %v", names)
+	}
+}
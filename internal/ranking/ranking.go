@@ -0,0 +1,70 @@
+package ranking
+
+import "strings"
+
+// Weights scale how much each field counts towards a search score.
+type Weights struct {
+	Name    float64
+	Alias   float64
+	Tag     float64
+	Meta    float64 // front matter other than tags, aliases and variables
+	Content float64
+}
+
+// IsZero reports whether every weight is zero, in which case no prompt can
+// match a query.
+func (w Weights) IsZero() bool {
+	return w == Weights{}
+}
+
+// Ranking controls how prompts are scored: the field weights, the cutoff
+// below which a weighted sum does not match and how many runes of content
+// are searched. A weight of zero ignores its field.
+type Ranking struct {
+	Weights       Weights
+	Cutoff        float64
+	ContentWindow int
+}
+
+// Names of the presets.
+const (
+	Balanced     = "balanced"
+	NameFirst    = "name-first"
+	ContentFirst = "content-first"
+)
+
+// Names lists the presets, the default first.
+var Names = []string{Balanced, NameFirst, ContentFirst}
+
+var presets = map[string]Ranking{
+	Balanced: {
+		Weights:       Weights{Name: 5, Alias: 4, Tag: 3, Meta: 2, Content: 1},
+		Cutoff:        0.25,
+		ContentWindow: 2048,
+	},
+	// Matches in the content alone are not enough, and a stricter cutoff
+	// keeps loose fuzzy name matches out.
+	NameFirst: {
+		Weights:       Weights{Name: 10, Alias: 8, Tag: 4, Meta: 1, Content: 0},
+		Cutoff:        0.5,
+		ContentWindow: 2048,
+	},
+	// The whole of most prompts is searched, and the content outweighs a
+	// fuzzy name match.
+	ContentFirst: {
+		Weights:       Weights{Name: 2, Alias: 1, Tag: 1, Meta: 1, Content: 5},
+		Cutoff:        0.25,
+		ContentWindow: 16384,
+	},
+}
+
+// Preset returns the named preset. Names are matched case-insensitively.
+func Preset(name string) (Ranking, bool) {
+	r, ok := presets[strings.ToLower(strings.TrimSpace(name))]
+	return r, ok
+}
+
+// Default returns the balanced preset.
+func Default() Ranking {
+	return presets[Balanced]
+}
@@ -0,0 +1,25 @@
+package ranking
+
+import "testing"
+
+func TestPresetsAreListed(t *testing.T) {
+	for _, name := range Names {
+		if _, ok := Preset(name); !ok {
+			t.Fatalf("missing preset %q", name)
+		}
+	}
+	if len(Names) != len(presets) {
+		t.Fatalf("expected Names to list all %d presets, got %v", len(presets), Names)
+	}
+	if r, ok := Preset(" Name-First "); !ok || r.Weights.Content != 0 {
+		t.Fatalf("expected names to match loosely, got %+v %v", r, ok)
+	}
+	if Default() != presets[Balanced] {
+		t.Fatal("expected balanced to be the default")
+	}
+	for name, r := range presets {
+		if r.Weights.IsZero() {
+			t.Fatalf("expected preset %q to weigh some field", name)
+		}
+	}
+}
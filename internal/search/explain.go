@@ -0,0 +1,93 @@
+package search
+
+import (
+	"sort"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/ranking"
+)
+
+// Component is the part one field contributes to a score.
+type Component struct {
+	Field  string  // "name", "alias", "tag", "meta" or "content"
+	Score  float64 // how well the field matched: 0 to 1, content up to 1.5
+	Weight float64
+}
+
+// Weighted returns the component's share of the sum.
+func (c Component) Weighted() float64 {
+	return c.Score * c.Weight
+}
+
+// Explanation breaks the score of a prompt down into its components.
+type Explanation struct {
+	Components   []Component
+	Sum          float64 // weighted sum of the components
+	Cutoff       float64 // sums below the cutoff, or of zero, do not match
+	SourceWeight float64 // multiplier of the prompt's source; 1 when unset
+
+	// Word is set when a prompt was dropped because this word of a
+	// multi-word query scored under the cutoff on its own; the components
+	// are then those of the word.
+	Word string
+}
+
+// Explain works like Rank with the Explanation of every result filled in. It
+// also returns the prompts dropped by the cutoff: those that matched the
+// query's filters and partly matched its words, sorted by name.
+func Explain(prompts []prompt.Prompt, query string, opts Options) (results, dropped []Result) {
+	return rank(prompts, query, opts, true)
+}
+
+// explainScore scores each field of p against the query. r must have its
+// defaults filled in.
+func explainScore(p prompt.Prompt, rawQuery, normalizedQuery string, r ranking.Ranking) Explanation {
+	metaSkip := map[string]struct{}{
+		"tags":      {},
+		"aliases":   {},
+		"variables": {},
+	}
+
+	e := Explanation{
+		Components: []Component{
+			{Field: "name", Score: fuzzyScore(normalizedQuery, normalize(p.Name)), Weight: r.Weights.Name},
+			{Field: "alias", Score: bestScore(valuesFromFront(p.FrontMatter, "aliases"), normalizedQuery), Weight: r.Weights.Alias},
+			{Field: "tag", Score: bestScore(p.Tags, normalizedQuery), Weight: r.Weights.Tag},
+			{Field: "meta", Score: bestScore(collectFrontMatterStrings(p.FrontMatter, metaSkip), normalizedQuery), Weight: r.Weights.Meta},
+			{Field: "content", Score: contentRelevance(p.Content, p.SearchText, rawQuery, normalizedQuery, r.ContentWindow), Weight: r.Weights.Content},
+		},
+		Cutoff:       r.Cutoff,
+		SourceWeight: 1,
+	}
+	for _, c := range e.Components {
+		e.Sum += c.Weighted()
+	}
+	if p.Weight > 0 {
+		e.SourceWeight = p.Weight
+	}
+	return e
+}
+
+// matches reports whether the sum reaches the cutoff. A prompt that matched
+// nothing never does, even with a cutoff of zero.
+func (e Explanation) matches() bool {
+	return e.Sum > 0 && e.Sum >= e.Cutoff
+}
+
+// withDefaults returns the balanced preset for the zero Ranking and fills in
+// its content window when r has none.
+func withDefaults(r ranking.Ranking) ranking.Ranking {
+	if r == (ranking.Ranking{}) {
+		return ranking.Default()
+	}
+	if r.ContentWindow <= 0 {
+		r.ContentWindow = ranking.Default().ContentWindow
+	}
+	return r
+}
+
+func sortByName(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Prompt.Name < results[j].Prompt.Name
+	})
+}
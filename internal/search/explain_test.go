@@ -0,0 +1,83 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+)
+
+func TestExplainBreaksDownScores(t *testing.T) {
+	prompts := []prompt.Prompt{
+		{Name: "code-review", Tags: []string{"review"}, Content: "review the diff", Weight: 2},
+		{Name: "brainstorm", Content: "ideas"},
+	}
+
+	results, dropped := Explain(prompts, "review", Options{})
+	if len(results) != 1 || len(dropped) != 0 {
+		t.Fatalf("expected one result and nothing dropped, got %d and %d", len(results), len(dropped))
+	}
+
+	e := results[0].Explanation
+	if e == nil {
+		t.Fatal("expected an explanation")
+	}
+	want := map[string][2]float64{
+		"name":    {1, 5},
+		"alias":   {0, 4},
+		"tag":     {1, 3},
+		"meta":    {0, 2},
+		"content": {1.5, 1},
+	}
+	if len(e.Components) != len(want) {
+		t.Fatalf("expected %d components, got %+v", len(want), e.Components)
+	}
+	for _, c := range e.Components {
+		if w := want[c.Field]; !almostEqual(c.Score, w[0]) || c.Weight != w[1] {
+			t.Fatalf("unexpected %s component %+v", c.Field, c)
+		}
+	}
+	if !almostEqual(e.Sum, 9.5) || e.SourceWeight != 2 || e.Cutoff != 0.25 {
+		t.Fatalf("unexpected explanation %+v", e)
+	}
+	if !almostEqual(results[0].Score, e.Sum*e.SourceWeight) {
+		t.Fatalf("expected the score to be the weighted sum, got %v", results[0].Score)
+	}
+}
+
+func TestExplainReportsPromptsUnderTheCutoff(t *testing.T) {
+	near := prompt.Prompt{Name: "notes", Content: "a long text where the letters r e v i e w show up far apart"}
+	prompts := []prompt.Prompt{
+		near,
+		{Name: "review"},
+		{Name: "unrelated", Content: "xyz"},
+	}
+
+	results, dropped := Explain(prompts, "review", Options{})
+	if len(results) != 1 || results[0].Prompt.Name != "review" {
+		t.Fatalf("expected only review to match, got %+v", results)
+	}
+	if len(dropped) != 1 || dropped[0].Prompt.Name != "notes" {
+		t.Fatalf("expected notes to be dropped, got %+v", dropped)
+	}
+	e := dropped[0].Explanation
+	if e.Sum <= 0 || e.Sum >= e.Cutoff || e.Word != "" {
+		t.Fatalf("expected a sum under the cutoff, got %+v", e)
+	}
+
+	if ranked := Rank(prompts, "review", Options{}); len(ranked) != 1 || ranked[0].Explanation != nil {
+		t.Fatalf("expected Rank to leave explanations out, got %+v", ranked)
+	}
+}
+
+func TestExplainNamesTheWordThatFellShort(t *testing.T) {
+	p := prompt.Prompt{Name: "code-review", Content: "a brisk read of every file"}
+
+	results, dropped := Explain([]prompt.Prompt{p}, "code brief", Options{})
+	if len(results) != 0 || len(dropped) != 1 {
+		t.Fatalf("expected the prompt to be dropped, got %d results and %d dropped", len(results), len(dropped))
+	}
+	e := dropped[0].Explanation
+	if e.Word != "brief" || e.Sum <= 0 || e.Sum >= e.Cutoff {
+		t.Fatalf("expected brief to fall short, got %+v", e)
+	}
+}
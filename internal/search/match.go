@@ -0,0 +1,205 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+)
+
+// Span is the byte range [Start, End) of a matched part of a value.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Match says where a query matched one field of a prompt.
+type Match struct {
+	Field string // "name", "alias", "tag" or "content"
+	Value string // the name, alias, tag or content line that matched
+	Line  int    // line of Value in the content, from 1; 0 for other fields
+	Spans []Span // the matched parts of Value, in order
+}
+
+// matchPrompt finds the spans of the query in the name, aliases, tags and
+// the best matching content line. Names, aliases and tags match words as
+// substrings or, failing that, as fuzzy subsequences; content lines only
+// match whole substrings, since almost any long line holds the letters of a
+// word in order.
+func matchPrompt(p prompt.Prompt, q Query) []Match {
+	terms := fieldTerms(q)
+
+	var matches []Match
+	if spans := findSpans(p.Name, append(terms[""], terms["name"]...), true); len(spans) > 0 {
+		matches = append(matches, Match{Field: "name", Value: p.Name, Spans: spans})
+	}
+	for _, alias := range valuesFromFront(p.FrontMatter, "aliases") {
+		if spans := findSpans(alias, append(terms[""], terms["alias"]...), true); len(spans) > 0 {
+			matches = append(matches, Match{Field: "alias", Value: alias, Spans: spans})
+		}
+	}
+	for _, tag := range p.Tags {
+		if spans := findSpans(tag, append(terms[""], terms["tag"]...), true); len(spans) > 0 {
+			matches = append(matches, Match{Field: "tag", Value: tag, Spans: spans})
+		}
+	}
+	if m, ok := bestContentLine(p.Content, terms[""]); ok {
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// fieldTerms groups the words and the positive filter values of q by the
+// field they apply to, with "" for terms that apply to every field.
+func fieldTerms(q Query) map[string][]string {
+	terms := map[string][]string{"": append([]string(nil), q.Words...)}
+	for _, term := range q.Filters {
+		if term.Negate {
+			continue
+		}
+		switch term.Field {
+		case "", "name", "alias", "tag":
+			terms[term.Field] = append(terms[term.Field], term.Value)
+		}
+	}
+	return terms
+}
+
+// bestContentLine returns the content line that contains the most terms,
+// preferring the earliest line on a tie.
+func bestContentLine(content string, terms []string) (Match, bool) {
+	if content == "" || len(terms) == 0 {
+		return Match{}, false
+	}
+
+	var best Match
+	bestCount := 0
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		count := 0
+		var spans []Span
+		for _, term := range terms {
+			if found := substringSpans(line, term); len(found) > 0 {
+				count++
+				spans = append(spans, found...)
+			}
+		}
+		if count > bestCount {
+			bestCount = count
+			best = Match{Field: "content", Value: line, Line: i + 1, Spans: mergeSpans(spans)}
+		}
+	}
+	return best, bestCount > 0
+}
+
+// findSpans returns the merged spans of every term found in value. With
+// fuzzy, a term that is not a substring may match as a subsequence.
+func findSpans(value string, terms []string, fuzzy bool) []Span {
+	var spans []Span
+	for _, term := range terms {
+		found := substringSpans(value, term)
+		if len(found) == 0 && fuzzy {
+			found = subsequenceSpans(value, term)
+		}
+		spans = append(spans, found...)
+	}
+	return mergeSpans(spans)
+}
+
+// foldedRune is a lower-cased rune of a value with its byte range.
+type foldedRune struct {
+	r          rune
+	start, end int
+}
+
+func foldRunes(value string) []foldedRune {
+	runes := make([]foldedRune, 0, len(value))
+	for i, r := range value {
+		runes = append(runes, foldedRune{r: unicode.ToLower(r), start: i, end: i + len(string(r))})
+	}
+	return runes
+}
+
+// substringSpans finds every case-insensitive occurrence of term in value.
+func substringSpans(value, term string) []Span {
+	needle := []rune(strings.ToLower(strings.TrimSpace(term)))
+	if len(needle) == 0 {
+		return nil
+	}
+	runes := foldRunes(value)
+
+	var spans []Span
+	for i := 0; i+len(needle) <= len(runes); i++ {
+		matched := true
+		for j, r := range needle {
+			if runes[i+j].r != r {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			spans = append(spans, Span{Start: runes[i].start, End: runes[i+len(needle)-1].end})
+			i += len(needle) - 1
+		}
+	}
+	return spans
+}
+
+// subsequenceSpans matches the letters and digits of term in order, taking
+// the earliest position for each, and returns a span per matched rune.
+func subsequenceSpans(value, term string) []Span {
+	runes := foldRunes(value)
+	var spans []Span
+	pos := 0
+	for _, r := range strings.ToLower(term) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		for pos < len(runes) && runes[pos].r != r {
+			pos++
+		}
+		if pos == len(runes) {
+			return nil
+		}
+		spans = append(spans, Span{Start: runes[pos].start, End: runes[pos].end})
+		pos++
+	}
+	return spans
+}
+
+// mergeSpans sorts spans and joins those that overlap or touch.
+func mergeSpans(spans []Span) []Span {
+	if len(spans) < 2 {
+		return spans
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.Start <= last.End {
+			last.End = max(last.End, s.End)
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// Emphasize wraps the spans of value in before and after.
+func Emphasize(value string, spans []Span, before, after string) string {
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.Start < last || s.End > len(value) {
+			continue
+		}
+		b.WriteString(value[last:s.Start])
+		b.WriteString(before)
+		b.WriteString(value[s.Start:s.End])
+		b.WriteString(after)
+		last = s.End
+	}
+	b.WriteString(value[last:])
+	return b.String()
+}
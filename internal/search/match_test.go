@@ -0,0 +1,81 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+)
+
+func TestRankReportsMatchSpans(t *testing.T) {
+	p := prompt.Prompt{
+		Name:        "code-review",
+		Tags:        []string{"Review", "go"},
+		FrontMatter: map[string]any{"aliases": []any{"pr check"}},
+		Content:     "# Checklist\n\n  Look at the review notes and\nreview the review twice\n",
+	}
+
+	results := Rank([]prompt.Prompt{p}, "review", Options{Spans: true})
+	if len(results) != 1 {
+		t.Fatalf("expected one result, got %d", len(results))
+	}
+	got := describeMatches(results[0].Matches)
+	want := `name "code-review" [5,11)` + "\n" +
+		`tag "Review" [0,6)` + "\n" +
+		`content:3 "  Look at the review notes and" [14,20)` + "\n"
+	if got != want {
+		t.Fatalf("unexpected matches:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Fuzzy words highlight the letters they matched; content needs the
+	// whole word.
+	results = Rank([]prompt.Prompt{p}, "cdrv", Options{Spans: true})
+	if len(results) != 1 {
+		t.Fatalf("expected a fuzzy match, got %d results", len(results))
+	}
+	got = describeMatches(results[0].Matches)
+	want = `name "code-review" [0,1) [2,3) [5,6) [7,8)` + "\n"
+	if got != want {
+		t.Fatalf("unexpected fuzzy matches:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRankLeavesMatchesOutByDefault(t *testing.T) {
+	results := Rank([]prompt.Prompt{{Name: "review"}}, "review", Options{})
+	if len(results) != 1 || results[0].Matches != nil {
+		t.Fatalf("expected no matches without Options.Spans, got %+v", results)
+	}
+}
+
+func TestRankPrefersContentLineWithMostWords(t *testing.T) {
+	p := prompt.Prompt{Name: "notes", Content: "error here\nlater: error handling in go\nhandling"}
+
+	results := Rank([]prompt.Prompt{p}, "error handling", Options{Spans: true})
+	m, ok := results[0].FieldMatch("content")
+	if !ok || m.Line != 2 || len(m.Spans) != 2 {
+		t.Fatalf("expected line 2 with both words, got %+v", m)
+	}
+}
+
+func TestEmphasize(t *testing.T) {
+	got := Emphasize("héllo world", []Span{{Start: 0, End: 6}, {Start: 7, End: 12}}, "[", "]")
+	if got != "[héllo] [world]" {
+		t.Fatalf("unexpected emphasis %q", got)
+	}
+}
+
+func describeMatches(matches []Match) string {
+	var out string
+	for _, m := range matches {
+		out += m.Field
+		if m.Line > 0 {
+			out += fmt.Sprintf(":%d", m.Line)
+		}
+		out += fmt.Sprintf(" %q", m.Value)
+		for _, s := range m.Spans {
+			out += fmt.Sprintf(" [%d,%d)", s.Start, s.End)
+		}
+		out += "\n"
+	}
+	return out
+}
@@ -0,0 +1,179 @@
+package search
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+)
+
+// Query is a search query split into fuzzy free text and filter terms.
+//
+// The syntax supports field qualifiers (tag:, name:, alias:, path: and
+// meta.<key>:), "quoted phrases", negation with a leading '-' and implicit AND
+// between terms. Unqualified words make up the free text that is fuzzy scored.
+type Query struct {
+	Text    string
+	Words   []string
+	Filters []Term
+}
+
+// Term is a single filter of a query.
+type Term struct {
+	Field  string // tag, name, alias, path, meta.<key>, or empty for any field
+	Value  string
+	Negate bool
+}
+
+// ParseQuery parses raw into a Query. Qualifiers with an empty value are
+// ignored so that a half-typed query keeps the current results.
+func ParseQuery(raw string) Query {
+	var q Query
+	for _, tok := range tokenize(raw) {
+		if normalize(tok.value) == "" {
+			continue
+		}
+		if tok.field == "" && !tok.negate && !tok.quoted {
+			q.Words = append(q.Words, tok.value)
+			continue
+		}
+		q.Filters = append(q.Filters, Term{Field: tok.field, Value: tok.value, Negate: tok.negate})
+	}
+	q.Text = strings.Join(q.Words, " ")
+	return q
+}
+
+// Matches reports whether the prompt satisfies every filter term.
+func (q Query) Matches(p prompt.Prompt) bool {
+	for _, term := range q.Filters {
+		if term.matches(p) == term.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t Term) matches(p prompt.Prompt) bool {
+	value := normalize(t.Value)
+	switch {
+	case t.Field == "tag":
+		for _, tag := range p.Tags {
+			if normalize(tag) == value {
+				return true
+			}
+		}
+		return false
+	case t.Field == "name":
+		return strings.Contains(normalize(p.Name), value)
+	case t.Field == "alias":
+		return containsNormalized(valuesFromFront(p.FrontMatter, "aliases"), value)
+	case t.Field == "path":
+		return strings.Contains(strings.ToLower(filepath.ToSlash(p.Path)), strings.ToLower(t.Value))
+	case strings.HasPrefix(t.Field, "meta."):
+		return containsNormalized(metaValues(p.FrontMatter, strings.TrimPrefix(t.Field, "meta.")), value)
+	}
+
+	if strings.Contains(normalize(p.Name), value) ||
+		containsNormalized(p.Tags, value) ||
+		containsNormalized(collectFrontMatterStrings(p.FrontMatter, nil), value) {
+		return true
+	}
+	text := p.SearchText
+	if text == "" {
+		text = normalize(p.Content)
+	}
+	return strings.Contains(text, value)
+}
+
+func containsNormalized(values []string, value string) bool {
+	for _, v := range values {
+		if strings.Contains(normalize(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// metaValues looks up a dotted key such as "metadata.audience" in the front matter.
+func metaValues(front map[string]any, key string) []string {
+	var current any = front
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = nil
+		for k, v := range m {
+			if strings.EqualFold(k, part) {
+				current = v
+				break
+			}
+		}
+	}
+	return flattenValue(current)
+}
+
+type token struct {
+	field  string
+	value  string
+	negate bool
+	quoted bool
+}
+
+func tokenize(raw string) []token {
+	var tokens []token
+	runes := []rune(raw)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var tok token
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			tok.negate = true
+			i++
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '"' && runes[i] != ':' {
+			i++
+		}
+		if i < len(runes) && runes[i] == ':' {
+			if field := strings.ToLower(string(runes[start:i])); isField(field) {
+				tok.field = field
+				start = i + 1
+			}
+		}
+		i = start
+
+		var value strings.Builder
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if runes[i] == '"' {
+				tok.quoted = true
+				i++
+				for i < len(runes) && runes[i] != '"' {
+					value.WriteRune(runes[i])
+					i++
+				}
+				i++ // closing quote, if any
+				continue
+			}
+			value.WriteRune(runes[i])
+			i++
+		}
+
+		tok.value = strings.TrimSpace(value.String())
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+func isField(field string) bool {
+	switch field {
+	case "tag", "name", "alias", "path":
+		return true
+	}
+	return strings.HasPrefix(field, "meta.") && len(field) > len("meta.")
+}
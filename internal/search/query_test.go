@@ -0,0 +1,79 @@
+package search
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+)
+
+func TestParseQuery(t *testing.T) {
+	q := ParseQuery(`review tag:go -tag:draft "error handling" meta.metadata.stage:discovery http://x name:`)
+
+	wantWords := []string{"review", "http://x"}
+	if !reflect.DeepEqual(q.Words, wantWords) || q.Text != "review http://x" {
+		t.Fatalf("unexpected words %v (text %q)", q.Words, q.Text)
+	}
+
+	wantFilters := []Term{
+		{Field: "tag", Value: "go"},
+		{Field: "tag", Value: "draft", Negate: true},
+		{Value: "error handling"},
+		{Field: "meta.metadata.stage", Value: "discovery"},
+	}
+	if !reflect.DeepEqual(q.Filters, wantFilters) {
+		t.Fatalf("unexpected filters %#v", q.Filters)
+	}
+}
+
+func TestParseQueryQuotedQualifier(t *testing.T) {
+	q := ParseQuery(`alias:"spec summary"`)
+	want := []Term{{Field: "alias", Value: "spec summary"}}
+	if !reflect.DeepEqual(q.Filters, want) || q.Text != "" {
+		t.Fatalf("unexpected query %#v", q)
+	}
+}
+
+func TestSearchWithFieldQualifiers(t *testing.T) {
+	prompts := loadTestdata(t)
+
+	cases := map[string][]string{
+		"tag:launch":                 {"product-brief"},
+		"-tag:launch":                {"brainstorm", "code-review"},
+		"name:review":                {"code-review"},
+		`alias:"spec summary"`:       {"product-brief"},
+		"path:brainstorm.txt":        {"brainstorm"},
+		"meta.metadata.stage:discov": {"product-brief"},
+		`"critical paths"`:           {"code-review"},
+		"-brainstorm":                {"code-review", "product-brief"},
+		"tag:product brief":          {"product-brief"},
+		"tag:product code":           nil,
+		"meta.metadata.audience:eng": nil,
+	}
+
+	for query, want := range cases {
+		t.Run(query, func(t *testing.T) {
+			var got []string
+			for _, p := range Search(prompts, query, Options{}) {
+				got = append(got, p.Name)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Search(%q) = %v, want %v", query, got, want)
+			}
+		})
+	}
+}
+
+func loadTestdata(t *testing.T) []prompt.Prompt {
+	t.Helper()
+	opts := prompt.Options{
+		Extensions:  []string{".md", ".txt"},
+		MaxFileSize: 128 * 1024,
+	}
+	prompts, err := prompt.LoadFromDirs([]string{filepath.FromSlash("../../testdata/prompts")}, opts)
+	if err != nil {
+		t.Fatalf("LoadFromDirs() error = %v", err)
+	}
+	return prompts
+}
@@ -0,0 +1,104 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/ranking"
+)
+
+func TestRankingPresetsChangeOrder(t *testing.T) {
+	prompts := []prompt.Prompt{
+		{Name: "error-handling", Content: "Wrap failures with context."},
+		{Name: "guide", Content: "Return the error and log the error once."},
+	}
+
+	for _, tc := range []struct {
+		preset string
+		want   []string
+	}{
+		{ranking.Balanced, []string{"error-handling", "guide"}},
+		{ranking.ContentFirst, []string{"guide", "error-handling"}},
+		{ranking.NameFirst, []string{"error-handling"}},
+	} {
+		r, ok := ranking.Preset(tc.preset)
+		if !ok {
+			t.Fatalf("missing preset %q", tc.preset)
+		}
+		var got []string
+		for _, p := range Search(prompts, "error", Options{Ranking: r}) {
+			got = append(got, p.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: expected %v, got %v", tc.preset, tc.want, got)
+		}
+	}
+}
+
+func TestRankingDefaultsToBalanced(t *testing.T) {
+	p := prompt.Prompt{Name: "notes", Tags: []string{"review"}}
+
+	got := Rank([]prompt.Prompt{p}, "review", Options{})
+	want := Rank([]prompt.Prompt{p}, "review", Options{Ranking: ranking.Default()})
+	if len(got) != 1 || len(want) != 1 || !almostEqual(got[0].Score, want[0].Score) {
+		t.Fatalf("expected the zero ranking to score like balanced, got %+v and %+v", got, want)
+	}
+}
+
+func TestRankingHonoursZeroes(t *testing.T) {
+	prompts := []prompt.Prompt{
+		{Name: "notes", Content: "a long text where the letters r e v i e w show up far apart"},
+		{Name: "checklist", Content: "review the diff"},
+		{Name: "unrelated", Content: "xyz"},
+	}
+
+	// A zero cutoff keeps the weakest match, but not prompts that matched
+	// nothing at all.
+	r := ranking.Default()
+	r.Cutoff = 0
+	if got := names(Search(prompts, "review", Options{Ranking: r})); got != "checklist,notes" {
+		t.Fatalf("expected every partial match with a zero cutoff, got %s", got)
+	}
+
+	// A zero content weight ignores the content.
+	r = ranking.Default()
+	r.Weights.Content = 0
+	if got := Search(prompts, "review", Options{Ranking: r}); len(got) != 0 {
+		t.Fatalf("expected no matches without content, got %v", names(got))
+	}
+}
+
+func TestRankingContentWindow(t *testing.T) {
+	p := prompt.Prompt{Name: "notes", Content: strings.Repeat("filler ", 20) + "checklist"}
+
+	results, _ := Explain([]prompt.Prompt{p}, "checklist", Options{})
+	if got := contentScore(results[0]); got != 1.5 {
+		t.Fatalf("expected a normalized match inside the default window, got %v", got)
+	}
+
+	// Outside the window only the literal check of the whole content applies.
+	r := ranking.Default()
+	r.ContentWindow = 20
+	results, _ = Explain([]prompt.Prompt{p}, "checklist", Options{Ranking: r})
+	if got := contentScore(results[0]); got != 1 {
+		t.Fatalf("expected the window to cut the normalized match, got %v", got)
+	}
+}
+
+func names(prompts []prompt.Prompt) string {
+	var out []string
+	for _, p := range prompts {
+		out = append(out, p.Name)
+	}
+	return strings.Join(out, ",")
+}
+
+func contentScore(r Result) float64 {
+	for _, c := range r.Explanation.Components {
+		if c.Field == "content" {
+			return c.Score
+		}
+	}
+	return -1
+}
@@ -9,96 +9,128 @@ import (
 	"github.com/lithammer/fuzzysearch/fuzzy"
 
 	"github.com/hzionn/prompt-manager-cli/internal/prompt"
-)
-
-var normalizerReplacer = strings.NewReplacer(
-	"_", " ",
-	"-", " ",
-	"/", " ",
-	".", " ",
-	",", " ",
-	"\n", " ",
-	"\r", " ",
-	"\t", " ",
+	"github.com/hzionn/prompt-manager-cli/internal/ranking"
 )
 
 // Options configure search behaviour.
 type Options struct {
 	MaxResults int
+	Spans      bool // fill Result.Matches
+
+	// Ranking weighs the fields of a match. The zero Ranking is the
+	// balanced preset, and a zero ContentWindow its window.
+	ranking.Ranking
+}
+
+// Result is a prompt that matched a query together with its relevance score.
+// Results of an empty or filter-only query all score zero.
+type Result struct {
+	Prompt  prompt.Prompt
+	Score   float64
+	Matches []Match // where the query matched, when Options.Spans is set
+
+	// Explanation breaks Score down; it is only set by Explain.
+	Explanation *Explanation
 }
 
 // Search applies fuzzy matching to find prompts that best align with the query.
+// See ParseQuery for the supported query syntax.
 func Search(prompts []prompt.Prompt, query string, opts Options) []prompt.Prompt {
-	trimmed := strings.TrimSpace(query)
-	if trimmed == "" {
-		results := append([]prompt.Prompt(nil), prompts...)
-		sort.Slice(results, func(i, j int) bool {
-			return results[i].Name < results[j].Name
-		})
-		if opts.MaxResults > 0 && len(results) > opts.MaxResults {
-			return results[:opts.MaxResults]
-		}
-		return results
+	ranked := Rank(prompts, query, opts)
+	results := make([]prompt.Prompt, 0, len(ranked))
+	for _, r := range ranked {
+		results = append(results, r.Prompt)
 	}
+	return results
+}
 
-	qNorm := normalize(trimmed)
+// Rank works like Search but also reports the score of each result.
+func Rank(prompts []prompt.Prompt, query string, opts Options) []Result {
+	results, _ := rank(prompts, query, opts, false)
+	return results
+}
 
-	type scored struct {
-		score  float64
-		prompt prompt.Prompt
-	}
+// rank scores prompts against query. With explain, every result carries its
+// Explanation and the prompts dropped by the cutoff are returned as well.
+func rank(prompts []prompt.Prompt, query string, opts Options, explain bool) (matches, dropped []Result) {
+	q := ParseQuery(query)
+	qNorm := normalize(q.Text)
+	rank := withDefaults(opts.Ranking)
 
-	var matches []scored
 	for _, p := range prompts {
-		score := aggregateScore(p, trimmed, qNorm)
-		if score <= 0 {
+		if !q.Matches(p) {
+			continue
+		}
+		if q.Text == "" {
+			matches = append(matches, Result{Prompt: p})
+			continue
+		}
+		if word, e, failed := failedWord(p, q.Words, rank); failed {
+			if explain && e.Sum > 0 {
+				e.Word = word
+				dropped = append(dropped, Result{Prompt: p, Explanation: &e})
+			}
+			continue
+		}
+		e := explainScore(p, q.Text, qNorm, rank)
+		if !e.matches() {
+			if explain && e.Sum > 0 {
+				dropped = append(dropped, Result{Prompt: p, Explanation: &e})
+			}
 			continue
 		}
-		matches = append(matches, scored{score: score, prompt: p})
+		// The source weight scales relevance so that preferred sources win ties
+		// and close calls.
+		r := Result{Prompt: p, Score: e.Sum * e.SourceWeight}
+		if explain {
+			r.Explanation = &e
+		}
+		matches = append(matches, r)
 	}
 
 	sort.SliceStable(matches, func(i, j int) bool {
-		if almostEqual(matches[i].score, matches[j].score) {
-			return matches[i].prompt.Name < matches[j].prompt.Name
+		if almostEqual(matches[i].Score, matches[j].Score) {
+			return matches[i].Prompt.Name < matches[j].Prompt.Name
 		}
-		return matches[i].score > matches[j].score
+		return matches[i].Score > matches[j].Score
 	})
+	sortByName(dropped)
 
 	if opts.MaxResults > 0 && len(matches) > opts.MaxResults {
 		matches = matches[:opts.MaxResults]
 	}
-
-	results := make([]prompt.Prompt, 0, len(matches))
-	for _, m := range matches {
-		results = append(results, m.prompt)
+	if opts.Spans {
+		for i := range matches {
+			matches[i].Matches = matchPrompt(matches[i].Prompt, q)
+		}
 	}
-	return results
+	return matches, dropped
 }
 
-func aggregateScore(p prompt.Prompt, rawQuery, normalizedQuery string) float64 {
-	nameScore := fuzzyScore(normalizedQuery, normalize(p.Name))
-
-	aliasScore := bestScore(valuesFromFront(p.FrontMatter, "aliases"), normalizedQuery)
-	tagScore := bestScore(p.Tags, normalizedQuery)
-
-	metaSkip := map[string]struct{}{
-		"tags":    {},
-		"aliases": {},
+// FieldMatch returns the first match in field: "name", "alias", "tag" or
+// "content".
+func (r Result) FieldMatch(field string) (Match, bool) {
+	for _, m := range r.Matches {
+		if m.Field == field {
+			return m, true
+		}
 	}
-	metaScore := bestScore(collectFrontMatterStrings(p.FrontMatter, metaSkip), normalizedQuery)
-
-	contentScore := contentRelevance(p.Content, rawQuery, normalizedQuery)
-
-	total := (nameScore * 5.0) +
-		(aliasScore * 4.0) +
-		(tagScore * 3.0) +
-		(metaScore * 2.0) +
-		contentScore
+	return Match{}, false
+}
 
-	if total < 0.25 {
-		return 0
+// failedWord implements the implicit AND between free text words: with more
+// than one word, each must match the prompt on its own. It returns the first
+// word that does not, with its score.
+func failedWord(p prompt.Prompt, words []string, r ranking.Ranking) (string, Explanation, bool) {
+	if len(words) < 2 {
+		return "", Explanation{}, false
 	}
-	return total
+	for _, word := range words {
+		if e := explainScore(p, word, normalize(word), r); !e.matches() {
+			return word, e, true
+		}
+	}
+	return "", Explanation{}, false
 }
 
 func bestScore(values []string, query string) float64 {
@@ -112,12 +144,15 @@ func bestScore(values []string, query string) float64 {
 	return best
 }
 
-func contentRelevance(content, rawQuery, normalizedQuery string) float64 {
+func contentRelevance(content, searchText, rawQuery, normalizedQuery string, window int) float64 {
 	if content == "" || normalizedQuery == "" {
 		return 0
 	}
 
-	contentNorm := normalize(snippetForSearch(content, 2048))
+	if searchText == "" {
+		searchText = normalize(content)
+	}
+	contentNorm := snippetForSearch(searchText, window)
 	if contentNorm == "" {
 		return 0
 	}
@@ -223,11 +258,7 @@ func snippetForSearch(content string, maxRunes int) string {
 }
 
 func normalize(value string) string {
-	if value == "" {
-		return ""
-	}
-	clean := normalizerReplacer.Replace(strings.ToLower(value))
-	return strings.Join(strings.Fields(clean), " ")
+	return prompt.NormalizeText(value)
 }
 
 func almostEqual(a, b float64) bool {
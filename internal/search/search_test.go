@@ -96,3 +96,21 @@ func TestSearchUsesFrontMatterFields(t *testing.T) {
 		t.Fatalf("expected result to surface front matter match, got %s", results[0].Name)
 	}
 }
+
+func TestRankAppliesSourceWeight(t *testing.T) {
+	prompts := []prompt.Prompt{
+		{Name: "review", ID: "shared:review", Content: "Review code", Weight: 1},
+		{Name: "review", ID: "personal:review", Content: "Review code", Weight: 2},
+	}
+
+	results := Rank(prompts, "review", Options{})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Prompt.ID != "personal:review" {
+		t.Fatalf("expected the heavier source first, got %s", results[0].Prompt.ID)
+	}
+	if !almostEqual(results[0].Score, 2*results[1].Score) {
+		t.Fatalf("expected the weight to scale the score, got %v and %v", results[0].Score, results[1].Score)
+	}
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hzionn/prompt-manager-cli/internal/editor"
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+)
+
+// editorFinishedMsg is sent once the external editor has exited.
+type editorFinishedMsg struct {
+	prompt prompt.Prompt
+	err    error
+}
+
+// editCurrent hands the terminal to the user's editor for the highlighted prompt.
+func (m *selectorModel) editCurrent() tea.Cmd {
+	if len(m.filtered) == 0 {
+		return nil
+	}
+
+	selected := m.filtered[m.cursor]
+	if selected.ReadOnly {
+		m.status = "prompt " + selected.ID + " is in read-only source " + selected.Source
+		return nil
+	}
+	cmd, err := editor.Command(selected.Path)
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{prompt: selected, err: err}
+	})
+}
+
+// finishEdit reloads the edited prompt and keeps it highlighted in the list.
+func (m *selectorModel) finishEdit(msg editorFinishedMsg) {
+	if msg.err != nil {
+		m.status = "editor: " + msg.err.Error()
+		return
+	}
+
+	reloaded, err := prompt.Reload(msg.prompt)
+	if err != nil {
+		m.status = "reload: " + err.Error()
+		return
+	}
+	m.status = ""
+
+	for i, p := range m.allPrompts {
+		if p.Path == reloaded.Path {
+			m.allPrompts[i] = reloaded
+		}
+	}
+	for i, p := range m.marked {
+		if p.Path == reloaded.Path {
+			m.marked[i] = reloaded
+		}
+	}
+
+	m.applyQuery(m.query)
+	for i, p := range m.filtered {
+		if p.Path == reloaded.Path {
+			m.cursor = i
+			break
+		}
+	}
+}
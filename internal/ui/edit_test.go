@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/search"
+)
+
+func TestSelectorModelEditWithoutEditorShowsStatus(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+
+	model := newSelectorModel([]prompt.Prompt{{Name: "alpha", Path: "alpha.md"}}, "", search.Options{})
+	next, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	model = next.(*selectorModel)
+
+	if cmd != nil {
+		t.Fatal("expected no editor command without $VISUAL or $EDITOR")
+	}
+	if !strings.Contains(model.View(), "no editor configured") {
+		t.Fatalf("expected status about the missing editor, got %q", model.View())
+	}
+}
+
+func TestSelectorModelReloadsPromptAfterEdit(t *testing.T) {
+	dir := t.TempDir()
+	alpha := filepath.Join(dir, "alpha.md")
+	beta := filepath.Join(dir, "beta.md")
+	for path, content := range map[string]string{alpha: "old alpha\n", beta: "beta\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	prompts := []prompt.Prompt{
+		{Name: "alpha", Path: alpha, Content: "old alpha\n"},
+		{Name: "beta", Path: beta, Content: "beta\n"},
+	}
+	model := newSelectorModel(prompts, "", search.Options{})
+	model.moveDown()
+
+	if err := os.WriteFile(beta, []byte("---\ntags: [edited]\n---\nnew beta\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	next, _ := model.Update(editorFinishedMsg{prompt: model.filtered[model.cursor]})
+	model = next.(*selectorModel)
+
+	current := model.filtered[model.cursor]
+	if current.Name != "beta" || current.Content != "new beta\n" || len(current.Tags) != 1 {
+		t.Fatalf("expected beta to be reloaded and highlighted, got %#v", current)
+	}
+}
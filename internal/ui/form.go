@@ -0,0 +1,262 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+)
+
+// Selection is a chosen prompt together with the template values entered for it.
+type Selection struct {
+	Prompt prompt.Prompt
+	Values map[string]string
+}
+
+// variableForm is the fill-in step shown after picking a prompt that has
+// template variables. It works on the prompt with its includes expanded so
+// that variables of included prompts are asked for as well.
+type variableForm struct {
+	selected prompt.Prompt
+	prompt   prompt.Prompt
+	fields   []formField
+	focus    int
+	err      string
+}
+
+type formField struct {
+	variable prompt.Variable
+	value    string
+}
+
+func newVariableForm(selected, expanded prompt.Prompt, preset map[string]string) *variableForm {
+	form := &variableForm{selected: selected, prompt: expanded}
+	for _, v := range expanded.TemplateVariables() {
+		value, ok := preset[v.Name]
+		if !ok {
+			value = v.Default
+		}
+		form.fields = append(form.fields, formField{variable: v, value: value})
+	}
+	return form
+}
+
+// values returns the entered values. Empty fields are left out so that they
+// fall back to their defaults or are reported as unfilled.
+func (f *variableForm) values() map[string]string {
+	values := make(map[string]string, len(f.fields))
+	for _, field := range f.fields {
+		if field.value != "" {
+			values[field.variable.Name] = field.value
+		}
+	}
+	return values
+}
+
+// submit validates the entered values, reporting the problem on the form when
+// they cannot be rendered.
+func (f *variableForm) submit() bool {
+	if _, err := prompt.Render(f.prompt, prompt.RenderOptions{Values: f.values()}); err != nil {
+		f.err = err.Error()
+		return false
+	}
+	f.err = ""
+	return true
+}
+
+func (f *variableForm) next() {
+	f.focus = (f.focus + 1) % len(f.fields)
+}
+
+func (f *variableForm) prev() {
+	if f.focus == 0 {
+		f.focus = len(f.fields) - 1
+		return
+	}
+	f.focus--
+}
+
+func (f *variableForm) current() *formField {
+	return &f.fields[f.focus]
+}
+
+// cycle steps through the options of an enum field.
+func (f *variableForm) cycle(step int) {
+	field := f.current()
+	options := field.variable.Options
+	if field.variable.Type != prompt.VariableEnum || len(options) == 0 {
+		return
+	}
+	idx := -1
+	for i, option := range options {
+		if strings.EqualFold(option, field.value) {
+			idx = i
+			break
+		}
+	}
+	idx = (idx + step + len(options)) % len(options)
+	field.value = options[idx]
+}
+
+func (f *variableForm) insert(runes []rune) {
+	field := f.current()
+	text := string(runes)
+	if field.variable.Type != prompt.VariableMultiline {
+		text = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(text)
+	}
+	field.value += text
+	f.err = ""
+}
+
+func (f *variableForm) backspace() {
+	field := f.current()
+	runes := []rune(field.value)
+	if len(runes) == 0 {
+		return
+	}
+	field.value = string(runes[:len(runes)-1])
+	f.err = ""
+}
+
+func (m *selectorModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := m.form
+	switch msg.String() {
+	case "ctrl+c":
+		m.cancelled = true
+		return m, tea.Quit
+	case "esc":
+		m.form = nil
+		m.mode = m.formReturnMode
+		return m, nil
+	case "enter":
+		if form.focus < len(form.fields)-1 {
+			form.next()
+			return m, nil
+		}
+		if form.submit() {
+			return m, tea.Quit
+		}
+		return m, nil
+	case "ctrl+s":
+		if form.submit() {
+			return m, tea.Quit
+		}
+		return m, nil
+	case "tab", "down":
+		form.next()
+	case "shift+tab", "up":
+		form.prev()
+	case "left":
+		form.cycle(-1)
+	case "right":
+		form.cycle(1)
+	case "ctrl+j", "alt+enter":
+		if form.current().variable.Type == prompt.VariableMultiline {
+			form.insert([]rune{'\n'})
+		}
+	case "backspace", "ctrl+h":
+		form.backspace()
+	case "ctrl+u":
+		form.current().value = ""
+	case " ":
+		form.insert([]rune{' '})
+	default:
+		if msg.Type == tea.KeyRunes && len(msg.Runes) > 0 {
+			form.insert(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+func (f *variableForm) view(width int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n Fill in variables for %s\n", f.prompt.Name)
+	b.WriteString(" Tab/↑/↓ move, ←/→ cycle options, Enter next/submit, Ctrl+S submits, Esc back, Ctrl+C cancels\n\n")
+
+	for i, field := range f.fields {
+		label := field.variable.Name
+		if kind := fieldKind(field.variable); kind != "" {
+			label += " (" + kind + ")"
+		}
+		if field.variable.Required {
+			label += " *"
+		}
+
+		value := strings.ReplaceAll(field.value, "\n", "⏎")
+		line := fmt.Sprintf("  %s: %s", label, value)
+		if i == f.focus {
+			line = highlight(truncate(line+"▏", width-1))
+		} else {
+			line = truncate(line, width-1)
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+
+		if desc := field.variable.Description; desc != "" {
+			b.WriteString(indent(wrap(desc, max(width-6, 20)), "      "))
+			b.WriteByte('\n')
+		}
+	}
+
+	if f.err != "" {
+		b.WriteString("\n  " + f.err + "\n")
+	}
+
+	rendered, _ := prompt.Render(f.prompt, prompt.RenderOptions{Values: f.values(), Partial: true})
+	if preview := snippet(rendered, 10); preview != "" {
+		b.WriteString("\nPreview:\n")
+		b.WriteString(indent(preview, "  "))
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+func fieldKind(v prompt.Variable) string {
+	switch v.Type {
+	case prompt.VariableEnum:
+		return strings.Join(v.Options, "|")
+	case prompt.VariableNumber, prompt.VariableMultiline:
+		return string(v.Type)
+	}
+	return ""
+}
+
+// fillVariablesFallback asks for each variable of the expanded prompt on its
+// own line when no terminal UI is available. Empty answers keep the default.
+func fillVariablesFallback(p prompt.Prompt, preset map[string]string, reader *bufio.Scanner, out io.Writer) map[string]string {
+	values := make(map[string]string)
+	for key, value := range preset {
+		values[key] = value
+	}
+
+	for _, v := range p.TemplateVariables() {
+		if _, ok := values[v.Name]; ok {
+			continue
+		}
+
+		label := v.Name
+		if v.Description != "" {
+			label += " - " + v.Description
+		}
+		if v.Default != "" {
+			label += " [" + v.Default + "]"
+		}
+		fmt.Fprintf(out, "%s: ", label)
+
+		value := v.Default
+		if reader.Scan() {
+			if text := strings.TrimSpace(reader.Text()); text != "" {
+				value = text
+			}
+		}
+		if value != "" {
+			values[v.Name] = value
+		}
+	}
+	return values
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/search"
+)
+
+func templatePrompt() prompt.Prompt {
+	return prompt.Prompt{
+		Name:    "review",
+		Content: "Review {{language}} code for {{focus}}.",
+		Variables: []prompt.Variable{
+			{Name: "language", Type: prompt.VariableEnum, Default: "Go", Options: []string{"Go", "Rust"}, Description: "Language under review"},
+		},
+	}
+}
+
+func TestSelectorModelOpensFormForTemplates(t *testing.T) {
+	model := newSelectorModel([]prompt.Prompt{templatePrompt()}, "", search.Options{})
+	model.fill = true
+
+	next, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = next.(*selectorModel)
+	if cmd != nil || model.mode != modeForm {
+		t.Fatalf("expected enter to open the variable form, mode = %v", model.mode)
+	}
+
+	view := model.View()
+	if !strings.Contains(view, "language (Go|Rust): Go") || !strings.Contains(view, "Language under review") {
+		t.Fatalf("expected form to show default and description, got %q", view)
+	}
+
+	next, _ = model.Update(tea.KeyMsg{Type: tea.KeyRight})
+	model = next.(*selectorModel)
+	next, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	model = next.(*selectorModel)
+	for _, r := range "safety" {
+		next, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		model = next.(*selectorModel)
+	}
+
+	if view := model.View(); !strings.Contains(view, "Review Rust code for safety.") {
+		t.Fatalf("expected live preview with entered values, got %q", view)
+	}
+
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected submitting the last field to quit")
+	}
+	values := model.form.values()
+	if values["language"] != "Rust" || values["focus"] != "safety" {
+		t.Fatalf("unexpected form values %v", values)
+	}
+}
+
+func TestSelectorModelFormReportsMissingValues(t *testing.T) {
+	model := newSelectorModel([]prompt.Prompt{templatePrompt()}, "", search.Options{})
+	model.fill = true
+
+	next, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = next.(*selectorModel)
+
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if cmd != nil {
+		t.Fatal("expected submit to be rejected while focus is empty")
+	}
+	if !strings.Contains(model.View(), "focus") || model.form.err == "" {
+		t.Fatalf("expected form error about focus, got %q", model.form.err)
+	}
+
+	next, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model = next.(*selectorModel)
+	if model.mode != modeFilter || model.form != nil {
+		t.Fatal("expected esc to return to the list")
+	}
+}
+
+func TestFillVariablesFallbackReadsLines(t *testing.T) {
+	reader := bufio.NewScanner(strings.NewReader("\nsafety\n"))
+	var out bytes.Buffer
+
+	values := fillVariablesFallback(templatePrompt(), nil, reader, &out)
+	if values["language"] != "Go" || values["focus"] != "safety" {
+		t.Fatalf("unexpected values %v", values)
+	}
+	if !strings.Contains(out.String(), "language - Language under review [Go]: ") {
+		t.Fatalf("expected question with description and default, got %q", out.String())
+	}
+}
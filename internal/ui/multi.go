@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/search"
+)
+
+// SelectPrompts presents the picker in multi-select mode: Tab marks and
+// unmarks prompts, and Enter returns the marked prompts in the order they
+// were marked, or the prompt under the cursor when none are.
+func SelectPrompts(prompts []prompt.Prompt, initialQuery string, opts search.Options, in io.Reader, out io.Writer) ([]prompt.Prompt, error) {
+	if len(prompts) == 0 {
+		return nil, ErrNoPrompts
+	}
+
+	if isTerminal(in) && isTerminal(out) {
+		model := newSelectorModel(prompts, initialQuery, opts)
+		model.multi = true
+		sel, err := runSelector(model, in, out)
+		if err == nil {
+			return sel.chosen(), nil
+		}
+		if errors.Is(err, ErrInvalidSelection) {
+			return nil, err
+		}
+		// If the TUI fails for any other reason, fall back to the simple selector.
+	}
+
+	display := prompts
+	if trimmed := strings.TrimSpace(initialQuery); trimmed != "" {
+		if matches := search.Search(prompts, trimmed, opts); len(matches) > 0 {
+			display = matches
+		}
+	}
+	return selectPromptsFallback(display, bufio.NewScanner(in), out)
+}
+
+// toggleMark marks the prompt under the cursor, or unmarks it and renumbers
+// the prompts marked after it, then moves to the next prompt.
+func (m *selectorModel) toggleMark() {
+	if len(m.filtered) == 0 {
+		return
+	}
+	current := m.filtered[m.cursor]
+	if i := m.markIndex(current); i >= 0 {
+		m.marked = append(m.marked[:i], m.marked[i+1:]...)
+	} else {
+		m.marked = append(m.marked, current)
+	}
+	m.moveDown()
+}
+
+// markIndex returns the position of p among the marked prompts, or -1.
+func (m *selectorModel) markIndex(p prompt.Prompt) int {
+	for i, marked := range m.marked {
+		if markKey(marked) == markKey(p) {
+			return i
+		}
+	}
+	return -1
+}
+
+// chosen returns the marked prompts, or the prompt under the cursor when none
+// are marked.
+func (m *selectorModel) chosen() []prompt.Prompt {
+	if len(m.marked) > 0 {
+		return append([]prompt.Prompt(nil), m.marked...)
+	}
+	return []prompt.Prompt{m.filtered[m.cursor]}
+}
+
+// markPrefix is the column in front of each prompt in multi-select mode,
+// holding its position in the selection.
+func (m *selectorModel) markPrefix(p prompt.Prompt) string {
+	if i := m.markIndex(p); i >= 0 {
+		return fmt.Sprintf("%2d ", i+1)
+	}
+	return "   "
+}
+
+func markKey(p prompt.Prompt) string {
+	if p.Path != "" {
+		return p.Path
+	}
+	return p.Name
+}
+
+// selectPromptsFallback asks for a comma separated list of numbers or names
+// and returns the prompts in the order given.
+func selectPromptsFallback(prompts []prompt.Prompt, reader *bufio.Scanner, out io.Writer) ([]prompt.Prompt, error) {
+	fmt.Fprintln(out, "Select prompts (comma separated, in order):")
+	for idx, p := range prompts {
+		fmt.Fprintf(out, "%d) %s\n", idx+1, p.Name)
+	}
+	fmt.Fprint(out, "> ")
+
+	if !reader.Scan() {
+		return prompts[:1], nil
+	}
+
+	var selected []prompt.Prompt
+	for _, part := range strings.Split(reader.Text(), ",") {
+		text := strings.TrimSpace(part)
+		if text == "" {
+			continue
+		}
+		p, err := matchSelection(prompts, text)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, p)
+	}
+	if len(selected) == 0 {
+		return prompts[:1], nil
+	}
+	return selected, nil
+}
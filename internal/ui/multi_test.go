@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/search"
+)
+
+func TestSelectorModelMarksPromptsInOrder(t *testing.T) {
+	prompts := []prompt.Prompt{
+		{Name: "alpha"},
+		{Name: "beta"},
+		{Name: "gamma"},
+	}
+	model := newSelectorModel(prompts, "", search.Options{})
+	model.multi = true
+
+	press := func(msg tea.KeyMsg) tea.Cmd {
+		next, cmd := model.Update(msg)
+		model = next.(*selectorModel)
+		return cmd
+	}
+
+	// Mark gamma, then alpha, then beta, and unmark gamma again.
+	press(tea.KeyMsg{Type: tea.KeyUp})
+	press(tea.KeyMsg{Type: tea.KeyTab})
+	press(tea.KeyMsg{Type: tea.KeyTab})
+	press(tea.KeyMsg{Type: tea.KeyTab})
+	if got := model.markPrefix(prompts[1]); got != " 3 " {
+		t.Fatalf("expected beta to be marked third, got %q", got)
+	}
+	press(tea.KeyMsg{Type: tea.KeyTab})
+
+	assertPromptNames(t, model.marked, []string{"alpha", "beta"})
+	if got := model.markPrefix(prompts[1]); got != " 2 " {
+		t.Fatalf("expected beta to move up to second, got %q", got)
+	}
+	if !strings.Contains(model.View(), " 1 alpha") {
+		t.Fatalf("expected the view to number marked prompts, got %q", model.View())
+	}
+
+	if cmd := press(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+		t.Fatal("expected enter to quit with the marked prompts")
+	}
+	assertPromptNames(t, model.chosen(), []string{"alpha", "beta"})
+}
+
+func TestSelectorModelTabIgnoredInSingleMode(t *testing.T) {
+	model := newSelectorModel([]prompt.Prompt{{Name: "alpha"}, {Name: "beta"}}, "", search.Options{})
+
+	next, _ := model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	model = next.(*selectorModel)
+	if len(model.marked) != 0 || model.cursor != 0 {
+		t.Fatalf("expected tab to do nothing outside multi-select, got %d marked at %d", len(model.marked), model.cursor)
+	}
+	assertPromptNames(t, model.chosen(), []string{"alpha"})
+}
+
+func TestSelectPromptsFallbackKeepsOrder(t *testing.T) {
+	prompts := []prompt.Prompt{{Name: "alpha"}, {Name: "beta"}, {Name: "gamma"}}
+	var out bytes.Buffer
+
+	selected, err := SelectPrompts(prompts, "", search.Options{}, strings.NewReader("3, alpha\n"), &out)
+	if err != nil {
+		t.Fatalf("SelectPrompts error = %v", err)
+	}
+	assertPromptNames(t, selected, []string{"gamma", "alpha"})
+
+	_, err = selectPromptsFallback(prompts, bufio.NewScanner(strings.NewReader("1,9\n")), &out)
+	if !errors.Is(err, ErrInvalidSelection) {
+		t.Fatalf("expected ErrInvalidSelection, got %v", err)
+	}
+}
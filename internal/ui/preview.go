@@ -0,0 +1,246 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+)
+
+const (
+	// splitMinWidth is the narrowest terminal that shows the preview beside
+	// the list rather than below it.
+	splitMinWidth = 100
+	// defaultHeight is assumed until the terminal reports its size.
+	defaultHeight = 24
+)
+
+// layout gives the size of the list and preview panes.
+type layout struct {
+	split         bool // preview beside the list rather than below it
+	listWidth     int
+	listHeight    int
+	previewWidth  int
+	previewHeight int
+}
+
+func (m *selectorModel) size() (int, int) {
+	width, height := m.width, m.height
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = defaultHeight
+	}
+	return width, height
+}
+
+// layout splits the space below the header between the list and the preview.
+// Wide terminals put them side by side; narrow ones give the list at most
+// half of the rows and the preview the rest, below a divider.
+func (m *selectorModel) layout() layout {
+	width, height := m.size()
+	// One row is left free so that the last line does not scroll the screen.
+	body := max(height-len(m.header())-1, 4)
+
+	if width >= splitMinWidth {
+		listWidth := max(width*2/5, 24)
+		return layout{
+			split:         true,
+			listWidth:     listWidth,
+			listHeight:    body,
+			previewWidth:  width - listWidth - 3,
+			previewHeight: body,
+		}
+	}
+
+	listHeight := max(min(len(m.filtered), body/2), 1)
+	return layout{
+		listWidth:     width,
+		listHeight:    listHeight,
+		previewWidth:  width,
+		previewHeight: max(body-listHeight-1, 1),
+	}
+}
+
+// followCursor scrolls the list so that the cursor stays on screen and starts
+// the preview from the top when the cursor moves to another prompt.
+func (m *selectorModel) followCursor() {
+	height := m.layout().listHeight
+	if m.cursor < m.listOffset {
+		m.listOffset = m.cursor
+	}
+	if m.cursor >= m.listOffset+height {
+		m.listOffset = m.cursor - height + 1
+	}
+	m.listOffset = max(min(m.listOffset, len(m.filtered)-height), 0)
+
+	key := ""
+	if len(m.filtered) > 0 {
+		key = markKey(m.filtered[m.cursor])
+	}
+	if key != m.previewKey {
+		m.previewKey = key
+		m.previewOffset = 0
+	}
+}
+
+// scrollPreview moves the preview by delta lines, stopping at either end.
+func (m *selectorModel) scrollPreview(delta int) {
+	if len(m.filtered) == 0 {
+		return
+	}
+	l := m.layout()
+	total := len(previewLines(m.filtered[m.cursor], l.previewWidth))
+	m.previewOffset = max(min(m.previewOffset+delta, total-l.previewHeight), 0)
+}
+
+// visiblePreview returns the preview lines on screen, marking the last one
+// when more follow.
+func (m *selectorModel) visiblePreview(width, height int) []string {
+	lines := previewLines(m.filtered[m.cursor], width)
+	start := min(m.previewOffset, len(lines))
+	end := min(start+height, len(lines))
+	visible := append([]string(nil), lines[start:end]...)
+	if end < len(lines) && len(visible) > 0 {
+		visible[len(visible)-1] = dim("… PgDn for more")
+	}
+	return visible
+}
+
+// previewLines renders the prompt's metadata followed by its full content
+// with light markdown styling, wrapped to width.
+func previewLines(p prompt.Prompt, width int) []string {
+	width = max(width, 20)
+
+	var lines []string
+	if summary := frontMatterString(p.FrontMatter, "summary"); summary != "" {
+		lines = append(lines, bold("Summary"))
+		lines = append(lines, strings.Split(indent(wrap(summary, width-2), "  "), "\n")...)
+	}
+	if p.ID != "" {
+		lines = append(lines, bold("ID")+" "+p.ID)
+	}
+	if len(p.Tags) > 0 {
+		lines = append(lines, strings.Split(wrap(bold("Tags")+" "+strings.Join(p.Tags, ", "), width), "\n")...)
+	}
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	return append(lines, renderMarkdown(p.Content, width)...)
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletPattern  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	numberPattern  = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	inlineCode     = regexp.MustCompile("`[^`]+`")
+	strongText     = regexp.MustCompile(`\*\*[^*]+\*\*`)
+)
+
+// renderMarkdown styles headings, lists, quotes, code blocks and inline code
+// and bold text. Prose is wrapped to width with list items keeping their
+// indentation; code is cut at width instead.
+func renderMarkdown(content string, width int) []string {
+	var lines []string
+	inCode := false
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			lines = append(lines, dim(truncate(trimmed, width)))
+			continue
+		}
+		if inCode {
+			lines = append(lines, codeStyle(truncate(expandTabs(line), width)))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			lines = append(lines, "")
+		case headingPattern.MatchString(trimmed):
+			text := headingPattern.FindStringSubmatch(trimmed)[2]
+			for _, part := range strings.Split(wrap(text, width), "\n") {
+				lines = append(lines, heading(part))
+			}
+		case bulletPattern.MatchString(line):
+			match := bulletPattern.FindStringSubmatch(line)
+			lines = append(lines, hangingWrap(match[1]+"• ", match[2], width)...)
+		case numberPattern.MatchString(line):
+			match := numberPattern.FindStringSubmatch(line)
+			lines = append(lines, hangingWrap(match[1]+match[2]+" ", match[3], width)...)
+		case strings.HasPrefix(trimmed, ">"):
+			text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			for _, part := range strings.Split(wrap(text, width-2), "\n") {
+				lines = append(lines, dim("│ "+part))
+			}
+		default:
+			lines = append(lines, hangingWrap("", trimmed, width)...)
+		}
+	}
+	return lines
+}
+
+// hangingWrap wraps text after marker, indenting continuation lines to line
+// up with the text, and styles inline markup on each line.
+func hangingWrap(marker, text string, width int) []string {
+	parts := strings.Split(wrap(text, max(width-displayWidth(marker), 10)), "\n")
+	lines := make([]string, 0, len(parts))
+	for i, part := range parts {
+		prefix := marker
+		if i > 0 {
+			prefix = strings.Repeat(" ", displayWidth(marker))
+		}
+		lines = append(lines, prefix+styleInline(part))
+	}
+	return lines
+}
+
+// styleInline styles `code` and **bold** spans that open and close on the
+// same line.
+func styleInline(line string) string {
+	line = inlineCode.ReplaceAllStringFunc(line, func(span string) string {
+		return codeStyle(strings.Trim(span, "`"))
+	})
+	return strongText.ReplaceAllStringFunc(line, func(span string) string {
+		return bold(strings.Trim(span, "*"))
+	})
+}
+
+func expandTabs(line string) string {
+	return strings.ReplaceAll(line, "\t", "    ")
+}
+
+// pad fills text with spaces up to width display columns.
+func pad(text string, width int) string {
+	if gap := width - displayWidth(text); gap > 0 {
+		return text + strings.Repeat(" ", gap)
+	}
+	return text
+}
+
+func lineAt(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+func heading(text string) string {
+	return "\x1b[1;38;5;111m" + text + "\x1b[0m"
+}
+
+func bold(text string) string {
+	return "\x1b[1m" + text + "\x1b[0m"
+}
+
+func dim(text string) string {
+	return "\x1b[2m" + text + "\x1b[0m"
+}
+
+func codeStyle(text string) string {
+	return "\x1b[38;5;180m" + text + "\x1b[0m"
+}
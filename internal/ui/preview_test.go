@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hzionn/prompt-manager-cli/internal/prompt"
+	"github.com/hzionn/prompt-manager-cli/internal/search"
+)
+
+func TestSelectorModelScrollsListToCursor(t *testing.T) {
+	var prompts []prompt.Prompt
+	for i := range 40 {
+		prompts = append(prompts, prompt.Prompt{Name: fmt.Sprintf("prompt-%02d", i)})
+	}
+	model := newSelectorModel(prompts, "", search.Options{})
+	model.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+
+	height := model.layout().listHeight
+	for range height + 5 {
+		model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+
+	view := stripANSI(model.View())
+	if !strings.Contains(view, fmt.Sprintf("prompt-%02d", height+5)) {
+		t.Fatalf("expected the cursor row to be on screen, got %q", view)
+	}
+	if strings.Contains(view, "prompt-00") {
+		t.Fatalf("expected the first rows to scroll off, got %q", view)
+	}
+	if lines := strings.Count(view, "\n"); lines >= 20 {
+		t.Fatalf("expected the view to fit 20 rows, got %d", lines)
+	}
+
+	// Wrapping around to the top scrolls back.
+	model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	for range height + 5 {
+		model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	}
+	if model.cursor != 39 || model.listOffset != 40-height {
+		t.Fatalf("expected the last page with the cursor on the last row, got cursor %d offset %d", model.cursor, model.listOffset)
+	}
+}
+
+func TestSelectorModelScrollsPreview(t *testing.T) {
+	var content strings.Builder
+	for i := range 60 {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	prompts := []prompt.Prompt{
+		{Name: "long", Path: "long.md", Content: content.String()},
+		{Name: "short", Path: "short.md", Content: "short"},
+	}
+	model := newSelectorModel(prompts, "", search.Options{})
+	model.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+
+	view := stripANSI(model.View())
+	if !strings.Contains(view, "line 0") || strings.Contains(view, "line 30") || !strings.Contains(view, "PgDn for more") {
+		t.Fatalf("expected the top of the preview, got %q", view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	model.Update(tea.KeyMsg{Type: tea.KeyShiftDown})
+	if want := model.layout().previewHeight; model.previewOffset != want {
+		t.Fatalf("expected a page and a line of scrolling (%d), got %d", want, model.previewOffset)
+	}
+	if view := stripANSI(model.View()); strings.Contains(view, "line 0\n") {
+		t.Fatalf("expected the preview to scroll, got %q", view)
+	}
+
+	for range 10 {
+		model.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	}
+	if view := stripANSI(model.View()); !strings.Contains(view, "line 59") || strings.Contains(view, "PgDn for more") {
+		t.Fatalf("expected scrolling to stop at the end, got %q", view)
+	}
+
+	model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if model.previewOffset != 0 {
+		t.Fatalf("expected another prompt to preview from the top, got offset %d", model.previewOffset)
+	}
+}
+
+func TestSelectorModelStacksPanesWhenNarrow(t *testing.T) {
+	model := newSelectorModel([]prompt.Prompt{{Name: "alpha", Content: "Alpha body"}}, "", search.Options{})
+	model.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+
+	view := stripANSI(model.View())
+	if strings.Contains(view, "│ Alpha body") || !strings.Contains(view, strings.Repeat("─", 60)+"\nAlpha body") {
+		t.Fatalf("expected the preview below the list, got %q", view)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	content := "# Review\n\nCheck **naming** and `errors`.\n\n- first item that is long enough to wrap\n  - nested\n\n```go\nfunc main() {}\n```\n> note"
+	got := renderMarkdown(content, 30)
+
+	want := []string{
+		heading("Review"),
+		"",
+		"Check " + bold("naming") + " and " + codeStyle("errors") + ".",
+		"",
+		"• first item that is long",
+		"  enough to wrap",
+		"  • nested",
+		"",
+		dim("```go"),
+		codeStyle("func main() {}"),
+		dim("```"),
+		dim("│ note"),
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected rendering:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
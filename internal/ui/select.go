@@ -35,17 +35,29 @@ func SelectPrompt(prompts []prompt.Prompt, in io.Reader, out io.Writer) (prompt.
 
 // SelectPromptWithQuery enables interactive filtering seeded with an initial query.
 func SelectPromptWithQuery(prompts []prompt.Prompt, initialQuery string, opts search.Options, in io.Reader, out io.Writer) (prompt.Prompt, error) {
+	selection, err := selectPrompt(prompts, initialQuery, opts, false, nil, in, out)
+	return selection.Prompt, err
+}
+
+// SelectAndFill works like SelectPromptWithQuery and then asks for the
+// template variables of the chosen prompt, pre-filled with preset values or
+// the declared defaults.
+func SelectAndFill(prompts []prompt.Prompt, initialQuery string, opts search.Options, preset map[string]string, in io.Reader, out io.Writer) (Selection, error) {
+	return selectPrompt(prompts, initialQuery, opts, true, preset, in, out)
+}
+
+func selectPrompt(prompts []prompt.Prompt, initialQuery string, opts search.Options, fill bool, preset map[string]string, in io.Reader, out io.Writer) (Selection, error) {
 	if len(prompts) == 0 {
-		return prompt.Prompt{}, ErrNoPrompts
+		return Selection{}, ErrNoPrompts
 	}
 
 	if isTerminal(in) && isTerminal(out) {
-		selected, err := runInteractiveSelector(prompts, initialQuery, opts, in, out)
+		selection, err := runInteractiveSelector(prompts, initialQuery, opts, fill, preset, in, out)
 		if err == nil {
-			return selected, nil
+			return selection, nil
 		}
 		if errors.Is(err, ErrInvalidSelection) {
-			return prompt.Prompt{}, err
+			return Selection{}, err
 		}
 		// If the TUI fails for any other reason, fall back to the simple selector.
 	}
@@ -57,12 +69,41 @@ func SelectPromptWithQuery(prompts []prompt.Prompt, initialQuery string, opts se
 		}
 	}
 
-	return selectPromptFallback(display, in, out)
+	reader := bufio.NewScanner(in)
+	selected, err := selectPromptFallback(display, reader, out)
+	if err != nil {
+		return Selection{}, err
+	}
+
+	selection := Selection{Prompt: selected, Values: preset}
+	if fill {
+		// Include errors are left for the caller to report when rendering.
+		if expanded, err := prompt.Expand(selected, prompts); err == nil {
+			selection.Values = fillVariablesFallback(expanded, preset, reader, out)
+		}
+	}
+	return selection, nil
 }
 
-func runInteractiveSelector(prompts []prompt.Prompt, initialQuery string, opts search.Options, in io.Reader, out io.Writer) (prompt.Prompt, error) {
+func runInteractiveSelector(prompts []prompt.Prompt, initialQuery string, opts search.Options, fill bool, preset map[string]string, in io.Reader, out io.Writer) (Selection, error) {
 	model := newSelectorModel(prompts, initialQuery, opts)
+	model.fill = fill
+	model.preset = preset
+
+	sel, err := runSelector(model, in, out)
+	if err != nil {
+		return Selection{}, err
+	}
+
+	if sel.form != nil {
+		return Selection{Prompt: sel.form.selected, Values: sel.form.values()}, nil
+	}
+	return Selection{Prompt: sel.filtered[sel.cursor], Values: preset}, nil
+}
 
+// runSelector runs model until it quits and returns its final state.
+// Cancelling, or confirming with nothing to choose, is ErrInvalidSelection.
+func runSelector(model *selectorModel, in io.Reader, out io.Writer) (*selectorModel, error) {
 	options := []tea.ProgramOption{
 		tea.WithInput(in),
 		tea.WithOutput(out),
@@ -74,25 +115,23 @@ func runInteractiveSelector(prompts []prompt.Prompt, initialQuery string, opts s
 	prog := tea.NewProgram(model, options...)
 	finalModel, err := prog.StartReturningModel()
 	if err != nil {
-		return prompt.Prompt{}, err
+		return nil, err
 	}
 
 	sel := finalModel.(*selectorModel)
-	if sel.cancelled || len(sel.filtered) == 0 {
-		return prompt.Prompt{}, ErrInvalidSelection
+	if sel.cancelled || (len(sel.filtered) == 0 && len(sel.marked) == 0) {
+		return nil, ErrInvalidSelection
 	}
-
-	return sel.filtered[sel.cursor], nil
+	return sel, nil
 }
 
-func selectPromptFallback(prompts []prompt.Prompt, in io.Reader, out io.Writer) (prompt.Prompt, error) {
+func selectPromptFallback(prompts []prompt.Prompt, reader *bufio.Scanner, out io.Writer) (prompt.Prompt, error) {
 	fmt.Fprintln(out, "Select a prompt:")
 	for idx, p := range prompts {
 		fmt.Fprintf(out, "%d) %s\n", idx+1, p.Name)
 	}
 	fmt.Fprint(out, "> ")
 
-	reader := bufio.NewScanner(in)
 	if !reader.Scan() {
 		return prompts[0], nil
 	}
@@ -101,7 +140,12 @@ func selectPromptFallback(prompts []prompt.Prompt, in io.Reader, out io.Writer)
 	if text == "" {
 		return prompts[0], nil
 	}
+	return matchSelection(prompts, text)
+}
 
+// matchSelection finds the prompt given by a 1-based number, an exact name or
+// the first partial name match.
+func matchSelection(prompts []prompt.Prompt, text string) (prompt.Prompt, error) {
 	if index, err := strconv.Atoi(text); err == nil {
 		if index < 1 || index > len(prompts) {
 			return prompt.Prompt{}, ErrInvalidSelection
@@ -143,8 +187,27 @@ type selectorModel struct {
 	height     int
 	ready      bool
 	query      string
+	matches    map[string][]search.Match // where the query matched each prompt
 	filterOpts search.Options
 	mode       selectorMode
+	status     string
+
+	// fill enables the variable form step after a prompt is chosen.
+	fill           bool
+	preset         map[string]string
+	form           *variableForm
+	formReturnMode selectorMode
+
+	// multi enables marking several prompts with Tab; marked keeps them in
+	// the order they were marked.
+	multi  bool
+	marked []prompt.Prompt
+
+	// listOffset is the first list row on screen; previewOffset is the first
+	// preview line of the prompt identified by previewKey.
+	listOffset    int
+	previewOffset int
+	previewKey    string
 }
 
 type selectorMode int
@@ -152,6 +215,7 @@ type selectorMode int
 const (
 	modeFilter selectorMode = iota
 	modeNavigate
+	modeForm
 )
 
 func newSelectorModel(prompts []prompt.Prompt, initialQuery string, opts search.Options) *selectorModel {
@@ -177,8 +241,18 @@ func (m *selectorModel) toggleMode() {
 }
 
 func (m *selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	m.followCursor()
+	return model, cmd
+}
+
+func (m *selectorModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.mode == modeForm {
+			return m.updateForm(msg)
+		}
+
 		switch msg.String() {
 		case "esc":
 			m.toggleMode()
@@ -186,6 +260,25 @@ func (m *selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c":
 			m.cancelled = true
 			return m, tea.Quit
+		case "ctrl+e":
+			return m, m.editCurrent()
+		case "tab":
+			if m.multi {
+				m.toggleMark()
+			}
+			return m, nil
+		case "pgdown":
+			m.scrollPreview(m.layout().previewHeight - 1)
+			return m, nil
+		case "pgup":
+			m.scrollPreview(-(m.layout().previewHeight - 1))
+			return m, nil
+		case "shift+down":
+			m.scrollPreview(1)
+			return m, nil
+		case "shift+up":
+			m.scrollPreview(-1)
+			return m, nil
 		case "backspace", "delete", "ctrl+h":
 			if m.mode == modeNavigate {
 				return m, nil
@@ -193,8 +286,15 @@ func (m *selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.backspace()
 			return m, nil
 		case "enter":
+			if m.multi && len(m.marked) > 0 {
+				return m, tea.Quit
+			}
 			if len(m.filtered) == 0 {
 				m.cancelled = true
+				return m, tea.Quit
+			}
+			if m.fill && m.openForm(m.filtered[m.cursor]) {
+				return m, nil
 			}
 			return m, tea.Quit
 		case "up", "ctrl+p":
@@ -225,11 +325,27 @@ func (m *selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.ready = true
+	case editorFinishedMsg:
+		m.finishEdit(msg)
 	}
 
 	return m, nil
 }
 
+// openForm switches to the variable form when the prompt has variables to
+// fill. Prompts whose includes cannot be expanded are returned as-is so the
+// caller can report the include error.
+func (m *selectorModel) openForm(selected prompt.Prompt) bool {
+	expanded, err := prompt.Expand(selected, m.allPrompts)
+	if err != nil || len(expanded.TemplateVariables()) == 0 {
+		return false
+	}
+	m.form = newVariableForm(selected, expanded, m.preset)
+	m.formReturnMode = m.mode
+	m.mode = modeForm
+	return true
+}
+
 func (m *selectorModel) handleRunes(runes []rune) (tea.Model, tea.Cmd) {
 	if len(runes) == 0 {
 		return m, nil
@@ -288,6 +404,7 @@ func (m *selectorModel) applyQuery(query string) {
 	m.query = query
 	trimmed := strings.TrimSpace(query)
 
+	m.matches = nil
 	if trimmed == "" {
 		m.filtered = append([]prompt.Prompt(nil), m.allPrompts...)
 		sortPrompts(m.filtered)
@@ -296,7 +413,14 @@ func (m *selectorModel) applyQuery(query string) {
 		if opts.MaxResults <= 0 || opts.MaxResults > len(m.allPrompts) {
 			opts.MaxResults = len(m.allPrompts)
 		}
-		m.filtered = search.Search(m.allPrompts, trimmed, opts)
+		opts.Spans = true
+		results := search.Rank(m.allPrompts, trimmed, opts)
+		m.filtered = make([]prompt.Prompt, 0, len(results))
+		m.matches = make(map[string][]search.Match, len(results))
+		for _, r := range results {
+			m.filtered = append(m.filtered, r.Prompt)
+			m.matches[markKey(r.Prompt)] = r.Matches
+		}
 	}
 
 	if len(m.filtered) == 0 {
@@ -332,17 +456,18 @@ func (m *selectorModel) moveDown() {
 }
 
 func (m *selectorModel) View() string {
-	width := m.width
-	if width <= 0 {
-		width = 80
+	width, _ := m.size()
+
+	if m.mode == modeForm {
+		return m.form.view(width)
 	}
 
 	var b strings.Builder
-	b.WriteString("\n Filter: " + m.query + "\n")
-	if m.mode == modeFilter {
-		b.WriteString(" Typing mode (Esc to switch to navigation). ↑/↓ move, Enter confirms, Ctrl+C cancels\n\n")
-	} else {
-		b.WriteString(" Navigation mode (Esc to switch to typing). ↑/↓/j/k move, Enter confirms, Ctrl+C cancels\n\n")
+	for _, line := range m.header() {
+		// Header lines are cut rather than wrapped so that the layout can
+		// count them.
+		b.WriteString(truncate(line, width))
+		b.WriteByte('\n')
 	}
 
 	if len(m.filtered) == 0 {
@@ -350,22 +475,71 @@ func (m *selectorModel) View() string {
 		return b.String()
 	}
 
-	for i, p := range m.filtered {
-		line := fmt.Sprintf("  %s", renderPromptTitle(p, width))
-		if i == m.cursor {
-			line = highlight(line)
+	l := m.layout()
+	list := m.listLines(l.listWidth, l.listHeight)
+	preview := m.visiblePreview(l.previewWidth, l.previewHeight)
+
+	if l.split {
+		for i := 0; i < l.listHeight; i++ {
+			b.WriteString(pad(lineAt(list, i), l.listWidth))
+			b.WriteString(" │ ")
+			b.WriteString(lineAt(preview, i))
+			b.WriteByte('\n')
 		}
+		return b.String()
+	}
+
+	for _, line := range list {
 		b.WriteString(line)
 		b.WriteByte('\n')
 	}
-
-	b.WriteByte('\n')
-	b.WriteString(renderPreview(m.filtered[m.cursor], width))
+	b.WriteString(dim(strings.Repeat("─", width)))
 	b.WriteByte('\n')
-
+	for _, line := range preview {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
 	return b.String()
 }
 
+// header returns the lines above the list: the filter, the key help and any
+// status message.
+func (m *selectorModel) header() []string {
+	lines := []string{"", " Filter: " + m.query}
+	if m.mode == modeFilter {
+		lines = append(lines, " Typing mode (Esc to switch to navigation). ↑/↓ move, PgUp/PgDn scroll the preview, Enter confirms, Ctrl+E edits, Ctrl+C cancels")
+	} else {
+		lines = append(lines, " Navigation mode (Esc to switch to typing). ↑/↓/j/k move, PgUp/PgDn scroll the preview, Enter confirms, Ctrl+E edits, Ctrl+C cancels")
+	}
+	if m.multi {
+		lines = append(lines, fmt.Sprintf(" Tab marks a prompt (%d marked); Enter returns the marked prompts in order", len(m.marked)))
+	}
+	if m.status != "" {
+		lines = append(lines, " "+m.status)
+	}
+	return append(lines, "")
+}
+
+// listLines renders the window of the filtered list that starts at the
+// scroll offset.
+func (m *selectorModel) listLines(width, height int) []string {
+	end := min(m.listOffset+height, len(m.filtered))
+	lines := make([]string, 0, end-m.listOffset)
+	for i := m.listOffset; i < end; i++ {
+		p := m.filtered[i]
+		matches := m.matches[markKey(p)]
+		line := fmt.Sprintf("  %s", renderPromptTitle(p, width, matches))
+		if m.multi {
+			line = fmt.Sprintf("  %s%s", m.markPrefix(p), renderPromptTitle(p, width-3, matches))
+		}
+		if i == m.cursor {
+			line = highlight(line)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
 func sortPrompts(prompts []prompt.Prompt) {
 	sort.Slice(prompts, func(i, j int) bool {
 		return prompts[i].Name < prompts[j].Name
@@ -384,34 +558,50 @@ func isDigits(runes []rune) bool {
 	return true
 }
 
-func renderPromptTitle(p prompt.Prompt, width int) string {
-	name := truncate(p.Name, width-4)
-	if len(p.Tags) == 0 {
-		return name
-	}
-	tagLine := strings.Join(p.Tags, ", ")
-	full := fmt.Sprintf("%s  [%s]", name, tagLine)
-	return truncate(full, width-4)
-}
-
-func renderPreview(p prompt.Prompt, width int) string {
-	width = max(width-2, 40)
-
-	var sections []string
-
-	if summary := frontMatterString(p.FrontMatter, "summary"); summary != "" {
-		sections = append(sections, "Summary:\n"+indent(wrap(summary, width), "  "))
-	}
-
+// renderPromptTitle renders the name and tags of p cut to width, emphasising
+// the characters the query matched.
+func renderPromptTitle(p prompt.Prompt, width int, matches []search.Match) string {
+	title := p.Name
+	spans := fieldSpans(matches, "name", p.Name, 0)
 	if len(p.Tags) > 0 {
-		sections = append(sections, "Tags: "+strings.Join(p.Tags, ", "))
+		title += "  ["
+		for i, tag := range p.Tags {
+			if i > 0 {
+				title += ", "
+			}
+			spans = append(spans, fieldSpans(matches, "tag", tag, len(title))...)
+			title += tag
+		}
+		title += "]"
 	}
 
-	if preview := snippet(p.Content, 5); preview != "" {
-		sections = append(sections, "Preview:\n"+indent(wrap(preview, width), "  "))
+	cut := truncate(title, width-4)
+	if cut != title {
+		// Keep the spans that are still fully visible before the ellipsis.
+		visible := len(cut) - len("…")
+		kept := spans[:0]
+		for _, s := range spans {
+			if s.End <= visible {
+				kept = append(kept, s)
+			}
+		}
+		spans = kept
 	}
+	return search.Emphasize(cut, spans, emphasisOn, emphasisOff)
+}
 
-	return strings.Join(sections, "\n\n")
+// fieldSpans returns the spans matched in value of field, moved by offset.
+func fieldSpans(matches []search.Match, field, value string, offset int) []search.Span {
+	var spans []search.Span
+	for _, m := range matches {
+		if m.Field != field || m.Value != value {
+			continue
+		}
+		for _, s := range m.Spans {
+			spans = append(spans, search.Span{Start: s.Start + offset, End: s.End + offset})
+		}
+	}
+	return spans
 }
 
 func frontMatterString(front map[string]any, key string) string {
@@ -522,6 +712,13 @@ func truncate(text string, length int) string {
 	return string(runes[:length-1]) + "…"
 }
 
+// emphasisOn and emphasisOff underline and embolden matched characters
+// without resetting the colour of a highlighted row.
+const (
+	emphasisOn  = "\x1b[1;4m"
+	emphasisOff = "\x1b[22;24m"
+)
+
 func highlight(text string) string {
 	return "\x1b[38;5;213m" + text + "\x1b[0m"
 }
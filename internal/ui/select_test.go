@@ -268,6 +268,45 @@ func TestSelectorModelInitialQuery(t *testing.T) {
 	}
 }
 
+func TestSelectorModelSupportsFieldQualifiers(t *testing.T) {
+	prompts := []prompt.Prompt{
+		{Name: "alpha", Tags: []string{"draft"}},
+		{Name: "beta", Tags: []string{"review"}},
+		{Name: "gamma", Tags: []string{"review", "draft"}},
+	}
+
+	model := newSelectorModel(prompts, "tag:", search.Options{})
+	assertPromptNames(t, model.filtered, []string{"alpha", "beta", "gamma"})
+
+	model.applyQuery("tag:review -tag:draft")
+	assertPromptNames(t, model.filtered, []string{"beta"})
+}
+
+func TestSelectorModelEmphasisesMatches(t *testing.T) {
+	prompts := []prompt.Prompt{
+		{Name: "code-review", Tags: []string{"go"}},
+		{Name: "brainstorm", Tags: []string{"review"}},
+	}
+
+	model := newSelectorModel(prompts, "review", search.Options{})
+	model.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	view := model.View()
+
+	for _, want := range []string{
+		"code-" + emphasisOn + "review" + emphasisOff,
+		"brainstorm  [" + emphasisOn + "review" + emphasisOff + "]",
+	} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected view to contain %q, got %q", want, view)
+		}
+	}
+
+	model.applyQuery("")
+	if strings.Contains(model.View(), emphasisOn) {
+		t.Fatal("expected no emphasis without a query")
+	}
+}
+
 func assertPromptNames(t *testing.T, prompts []prompt.Prompt, want []string) {
 	t.Helper()
 	if len(prompts) != len(want) {